@@ -23,5 +23,20 @@ func Authenticate(c *revel.Controller) revel.Result {
 	if user.WorkflowStatus == common.WorkflowStatusPending.GetStatusID() {
 		return c.Redirect(controllers.Account.Unauthorized)
 	}
+
+	// Unread notification count for the header
+	unreadNotifications, err := models.GetUnreadNotificationCount(user.GetUserID())
+	if err != nil {
+		c.Log.Errorf("Unable to get the unread notification count. Err: %s", err.Error())
+	}
+	c.ViewArgs["unreadNotifications"] = unreadNotifications
+
+	// Pending invitation count for the sidebar
+	pendingInvitations, err := models.GetPendingInvitationCount(user.GetUserID())
+	if err != nil {
+		c.Log.Errorf("Unable to get the pending invitation count. Err: %s", err.Error())
+	}
+	c.ViewArgs["pendingInvitations"] = pendingInvitations
+
 	return nil
 }
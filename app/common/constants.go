@@ -9,6 +9,30 @@ type WorkflowStatus int
 // ItemType is the enum for ItemTypes supported in the application
 type ItemType int
 
+// NotificationType is the enum for the types of notifications sent to the users
+type NotificationType int
+
+// GroupEventType is the enum for the types of events in the activity feed of a group
+type GroupEventType int
+
+// InvitationStatus is the enum for the status of the group invitations
+type InvitationStatus int
+
+// GroupRole is the enum for the roles of the members in a group
+type GroupRole int
+
+// GroupPermission is the enum for the group-scoped actions controlled by the roles
+type GroupPermission int
+
+// GroupDeletionStatus is the enum for the status of the background deletion of a group
+type GroupDeletionStatus int
+
+// ItemApprovalStatus is the enum for the approval of the items uploaded to the groups requiring upload approval
+type ItemApprovalStatus int
+
+// GroupVisibility is the enum for who can discover and read a group
+type GroupVisibility int
+
 const (
 
 	/*
@@ -61,6 +85,233 @@ const (
 	GroupMaxAllowedItems = 100
 	// GroupMaxAllowedSpace is the limit in max space the user is allocated in the app
 	GroupMaxAllowedSpace = 500.0
+
+	/*
+		SEARCH
+	*/
+
+	// SearchMaxResults is the maximum number of items returned for a search query
+	SearchMaxResults = 50
+	// SearchDateFormat is the format of the date range filters used in search
+	SearchDateFormat = "2006-01-02"
+
+	/*
+		FEED
+	*/
+
+	// FeedPageSize is the number of items returned per page of the item feed
+	FeedPageSize = 20
+	// FeedSortNewest sorts the feed by the upload time, newest first
+	FeedSortNewest = "newest"
+	// FeedSortOldest sorts the feed by the upload time, oldest first
+	FeedSortOldest = "oldest"
+	// FeedSortMostCommented sorts the feed by the number of comments, most commented first
+	FeedSortMostCommented = "commented"
+
+	/*
+		NOTIFICATION TYPES
+	*/
+
+	// NotificationMentionInComment is sent to a user mentioned in a comment
+	NotificationMentionInComment NotificationType = 1
+	// NotificationMentionInDescription is sent to a user mentioned in the description of an item
+	NotificationMentionInDescription NotificationType = 2
+	// NotificationJoinRequestReceived is sent to the leaders of a group when a user requests to join it
+	NotificationJoinRequestReceived NotificationType = 3
+	// NotificationJoinRequestApproved is sent to a user when the request to join a group is approved
+	NotificationJoinRequestApproved NotificationType = 4
+	// NotificationJoinRequestRejected is sent to a user when the request to join a group is rejected
+	NotificationJoinRequestRejected NotificationType = 5
+	// NotificationItemApproved is sent to the uploader when a leader approves an item pending for approval
+	NotificationItemApproved NotificationType = 6
+	// NotificationItemRejected is sent to the uploader when a leader rejects an item pending for approval
+	NotificationItemRejected NotificationType = 7
+
+	// NotificationsPageSize is the number of notifications listed on the notifications page
+	NotificationsPageSize = 50
+
+	/*
+		REACTIONS
+	*/
+
+	// DefaultReactions is the set of reactions used when none are configured in app.conf
+	DefaultReactions = "👍,❤️,😂,😮,😢,🎉"
+	// FeedTopReactions is the number of top reactions shown on the feed tiles
+	FeedTopReactions = 3
+
+	/*
+		ITEM VIEWS
+	*/
+
+	// ItemViewDebounceMinutes is the interval in which the repeated views of an item by a user are counted once
+	ItemViewDebounceMinutes = 30
+	// AnalyticsListSize is the number of items listed in each section of the group analytics
+	AnalyticsListSize = 10
+
+	/*
+		GROUP EVENTS
+	*/
+
+	// GroupEventItemUploaded is logged when an item is uploaded to the group
+	GroupEventItemUploaded GroupEventType = 1
+	// GroupEventItemDeleted is logged when an item of the group is deleted
+	GroupEventItemDeleted GroupEventType = 2
+	// GroupEventCommentAdded is logged when a comment is added to an item of the group
+	GroupEventCommentAdded GroupEventType = 3
+	// GroupEventMemberAdded is logged when a user is added to the group
+	GroupEventMemberAdded GroupEventType = 4
+	// GroupEventLeaderPromoted is logged when a member is promoted to a leader of the group
+	GroupEventLeaderPromoted GroupEventType = 5
+	// GroupEventLimitsChanged is logged when the upload limits of the group are changed
+	GroupEventLimitsChanged GroupEventType = 6
+	// GroupEventMemberRemoved is logged when a member is removed from the group
+	GroupEventMemberRemoved GroupEventType = 7
+	// GroupEventMemberLeft is logged when a member leaves the group
+	GroupEventMemberLeft GroupEventType = 8
+	// GroupEventMemberJoined is logged when a user joins the group by accepting an invitation
+	GroupEventMemberJoined GroupEventType = 9
+	// GroupEventRoleChanged is logged when the role of a member is changed
+	GroupEventRoleChanged GroupEventType = 10
+	// GroupEventRenamed is logged when the group is renamed
+	GroupEventRenamed GroupEventType = 11
+	// GroupEventArchived is logged when the group is archived
+	GroupEventArchived GroupEventType = 12
+	// GroupEventRestored is logged when an archived group is restored
+	GroupEventRestored GroupEventType = 13
+	// GroupEventLeaderDemoted is logged when a leader of the group is demoted to another role
+	GroupEventLeaderDemoted GroupEventType = 14
+	// GroupEventOwnershipTransferred is logged when the owner of the group hands the group over to another member
+	GroupEventOwnershipTransferred GroupEventType = 15
+	// GroupEventSettingsUpdated is logged when the settings of the group are updated
+	GroupEventSettingsUpdated GroupEventType = 16
+	// GroupEventVisibilityChanged is logged when the visibility of the group is changed
+	GroupEventVisibilityChanged GroupEventType = 17
+	// GroupEventParentChanged is logged when the group is moved under another group or to the top level
+	GroupEventParentChanged GroupEventType = 18
+
+	// ActivityPageSize is the number of events listed on a page of the activity feed
+	ActivityPageSize = 50
+
+	/*
+		ITEM SHARES
+	*/
+
+	// CommentScopeShared shows the comments of an item in all the groups it is shared into
+	CommentScopeShared = "shared"
+	// CommentScopeGroup shows the comments of an item only in the group they were posted in
+	CommentScopeGroup = "group"
+
+	/*
+		SHARE LINKS
+	*/
+
+	// ShareLinkTokenBytes is the number of random bytes in the token of a share link
+	ShareLinkTokenBytes = 24
+	// ShareLinkAccessOpen is logged when a share link is opened
+	ShareLinkAccessOpen = "open"
+	// ShareLinkAccessView is logged when the media of an item is viewed through a share link
+	ShareLinkAccessView = "view"
+	// ShareLinkAccessDownload is logged when an item is downloaded through a share link
+	ShareLinkAccessDownload = "download"
+	// ShareLinkAccessDenied is logged when the access through a share link is denied
+	ShareLinkAccessDenied = "denied"
+	// ShareLinkAccessLogSize is the number of latest accesses listed in the access log of a share link
+	ShareLinkAccessLogSize = 100
+
+	/*
+		GROUP MEMBERSHIP
+	*/
+
+	// MemberItemsKeep keeps the items of a departing member in the group
+	MemberItemsKeep = "keep"
+	// MemberItemsTransfer transfers the items of a departing member to a leader of the group
+	MemberItemsTransfer = "transfer"
+	// MemberItemsDelete deletes the items of a departing member from the group
+	MemberItemsDelete = "delete"
+
+	/*
+		GROUP INVITATIONS
+	*/
+
+	// InvitationPending is an invitation waiting for the invitee's response
+	InvitationPending InvitationStatus = 0
+	// InvitationAccepted is an invitation accepted by the invitee, the invitee is a member of the group
+	InvitationAccepted InvitationStatus = 1
+	// InvitationDeclined is an invitation declined by the invitee
+	InvitationDeclined InvitationStatus = 2
+	// InvitationRevoked is an invitation withdrawn by a leader of the group
+	InvitationRevoked InvitationStatus = 3
+
+	// InvitationTokenBytes is the number of random bytes in the token of an invitation
+	InvitationTokenBytes = 24
+	// InvitationExpiryDays is the number of days an invitation can be accepted in
+	InvitationExpiryDays = 7
+
+	/*
+		GROUP ROLES
+	*/
+
+	// GroupRoleNone is the role of the users who are not members of the group
+	GroupRoleNone GroupRole = 0
+	// GroupRoleViewer can only view and react to the content of the group
+	GroupRoleViewer GroupRole = 1
+	// GroupRoleContributor can upload, comment and delete own items
+	GroupRoleContributor GroupRole = 2
+	// GroupRoleModerator can also delete the items and comments of the others
+	GroupRoleModerator GroupRole = 3
+	// GroupRoleLeader can also manage the members and the settings of the group
+	GroupRoleLeader GroupRole = 4
+
+	// PermissionUpload allows uploading (and sharing) items into the group
+	PermissionUpload GroupPermission = 1
+	// PermissionComment allows commenting on the items of the group
+	PermissionComment GroupPermission = 2
+	// PermissionDeleteOwnItems allows deleting the items uploaded by the user
+	PermissionDeleteOwnItems GroupPermission = 3
+	// PermissionDeleteOthersItems allows deleting (moderating) the items and comments of the other members
+	PermissionDeleteOthersItems GroupPermission = 4
+	// PermissionManageMembers allows inviting, removing and changing the roles of the members
+	PermissionManageMembers GroupPermission = 5
+	// PermissionManageSettings allows managing the group itself, its settings and analytics
+	PermissionManageSettings GroupPermission = 6
+	// PermissionApproveItems allows uploading without approval and approving the uploads of the other members
+	PermissionApproveItems GroupPermission = 7
+
+	/*
+		GROUP DELETION
+	*/
+
+	// GroupDeletionRunning is a deletion still cleaning up the content of the group
+	GroupDeletionRunning GroupDeletionStatus = 0
+	// GroupDeletionCompleted is a deletion that removed the group and all its content
+	GroupDeletionCompleted GroupDeletionStatus = 1
+	// GroupDeletionFailed is a deletion stopped by an error, the group is left archived
+	GroupDeletionFailed GroupDeletionStatus = 2
+
+	// GroupDeletionBatchSize is the number of items deleted in one transaction of a group deletion
+	GroupDeletionBatchSize = 50
+	// GroupDeletionRefreshSeconds is the interval for refreshing the progress page of a running deletion
+	GroupDeletionRefreshSeconds = 3
+
+	/*
+		ITEM APPROVAL
+	*/
+
+	// ItemApprovalPublished is an item visible to the members of the group
+	ItemApprovalPublished ItemApprovalStatus = 0
+	// ItemApprovalPending is an item waiting for a leader's approval, visible only to the uploader and the leaders
+	ItemApprovalPending ItemApprovalStatus = 1
+
+	/*
+		GROUP VISIBILITY
+	*/
+
+	// GroupVisibilityHidden is a group known only to its members, users join it by invitation
+	GroupVisibilityHidden GroupVisibility = 0
+	// GroupVisibilityListed is a group listed in the directory, users request to join it
+	GroupVisibilityListed GroupVisibility = 1
+	// GroupVisibilityPublic is a group listed in the directory and readable by every approved user
+	GroupVisibilityPublic GroupVisibility = 2
 )
 
 // GetString returns string representation of workflow status
@@ -98,3 +349,181 @@ func (i ItemType) GetString() string {
 func (i ItemType) GetItemID() int {
 	return int(i)
 }
+
+// GetString returns string representation of the notification type
+func (n NotificationType) GetString() string {
+	switch n {
+	case NotificationMentionInComment:
+		return "mentioned you in a comment on"
+	case NotificationMentionInDescription:
+		return "mentioned you in the description of"
+	case NotificationJoinRequestReceived:
+		return "requested to join"
+	case NotificationJoinRequestApproved:
+		return "approved your request to join"
+	case NotificationJoinRequestRejected:
+		return "rejected your request to join"
+	case NotificationItemApproved:
+		return "approved your upload"
+	case NotificationItemRejected:
+		return "rejected your upload to"
+	}
+
+	return ""
+}
+
+// GetTypeID returns integer value associated with NotificationType enum
+func (n NotificationType) GetTypeID() int {
+	return int(n)
+}
+
+// GetString returns string representation of the group event type
+func (g GroupEventType) GetString() string {
+	switch g {
+	case GroupEventItemUploaded:
+		return "uploaded"
+	case GroupEventItemDeleted:
+		return "deleted"
+	case GroupEventCommentAdded:
+		return "commented on"
+	case GroupEventMemberAdded:
+		return "added"
+	case GroupEventLeaderPromoted:
+		return "promoted"
+	case GroupEventLimitsChanged:
+		return "changed the limits of"
+	case GroupEventMemberRemoved:
+		return "removed"
+	case GroupEventMemberLeft:
+		return "left"
+	case GroupEventMemberJoined:
+		return "joined"
+	case GroupEventRoleChanged:
+		return "changed the role of"
+	case GroupEventRenamed:
+		return "renamed"
+	case GroupEventArchived:
+		return "archived"
+	case GroupEventRestored:
+		return "restored"
+	case GroupEventLeaderDemoted:
+		return "demoted"
+	case GroupEventOwnershipTransferred:
+		return "transferred the ownership of the group to"
+	case GroupEventSettingsUpdated:
+		return "updated the settings of the group"
+	case GroupEventVisibilityChanged:
+		return "changed the visibility of the group to"
+	case GroupEventParentChanged:
+		return "moved the group"
+	}
+
+	return ""
+}
+
+// GetTypeID returns integer value associated with GroupEventType enum
+func (g GroupEventType) GetTypeID() int {
+	return int(g)
+}
+
+// GetString returns string representation of the invitation status
+func (i InvitationStatus) GetString() string {
+	switch i {
+	case InvitationPending:
+		return "Pending"
+	case InvitationAccepted:
+		return "Accepted"
+	case InvitationDeclined:
+		return "Declined"
+	case InvitationRevoked:
+		return "Revoked"
+	}
+
+	return ""
+}
+
+// GetStatusID returns integer status value associated with InvitationStatus enum
+func (i InvitationStatus) GetStatusID() int {
+	return int(i)
+}
+
+// GetString returns string representation of the group role
+func (r GroupRole) GetString() string {
+	switch r {
+	case GroupRoleViewer:
+		return "Viewer"
+	case GroupRoleContributor:
+		return "Contributor"
+	case GroupRoleModerator:
+		return "Moderator"
+	case GroupRoleLeader:
+		return "Group Leader"
+	}
+
+	return ""
+}
+
+// GetRoleID returns integer value associated with GroupRole enum
+func (r GroupRole) GetRoleID() int {
+	return int(r)
+}
+
+// GetString returns string representation of the group deletion status
+func (d GroupDeletionStatus) GetString() string {
+	switch d {
+	case GroupDeletionRunning:
+		return "Running"
+	case GroupDeletionCompleted:
+		return "Completed"
+	case GroupDeletionFailed:
+		return "Failed"
+	}
+
+	return ""
+}
+
+// GetStatusID returns integer status value associated with GroupDeletionStatus enum
+func (d GroupDeletionStatus) GetStatusID() int {
+	return int(d)
+}
+
+// GetString returns string representation of the item approval status
+func (a ItemApprovalStatus) GetString() string {
+	switch a {
+	case ItemApprovalPublished:
+		return "Published"
+	case ItemApprovalPending:
+		return "Pending for approval"
+	}
+
+	return ""
+}
+
+// GetStatusID returns integer status value associated with ItemApprovalStatus enum
+func (a ItemApprovalStatus) GetStatusID() int {
+	return int(a)
+}
+
+// GetString returns string representation of the group visibility
+func (v GroupVisibility) GetString() string {
+	switch v {
+	case GroupVisibilityHidden:
+		return "Hidden"
+	case GroupVisibilityListed:
+		return "Listed"
+	case GroupVisibilityPublic:
+		return "Public"
+	}
+
+	return ""
+}
+
+// GetVisibilityID returns integer value associated with GroupVisibility enum
+func (v GroupVisibility) GetVisibilityID() int {
+	return int(v)
+}
+
+// IsValid checks whether the visibility is one of the supported levels
+func (v GroupVisibility) IsValid() bool {
+	return v == GroupVisibilityHidden || v == GroupVisibilityListed || v == GroupVisibilityPublic
+}
@@ -0,0 +1,39 @@
+package common
+
+import "strings"
+
+// Reactions is the ordered set of emoji reactions allowed on the items and comments
+var Reactions []string
+
+// SetReactions sets the allowed reactions from a comma separated list
+func SetReactions(reactions string) {
+	Reactions = nil
+	for _, reaction := range strings.Split(reactions, ",") {
+		reaction = strings.TrimSpace(reaction)
+		if reaction != "" && !IsValidReaction(reaction) {
+			Reactions = append(Reactions, reaction)
+		}
+	}
+}
+
+// IsValidReaction checks whether the reaction is one of the allowed reactions
+func IsValidReaction(reaction string) bool {
+	for _, allowed := range Reactions {
+		if allowed == reaction {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetReactionOrder returns the position of the reaction in the allowed set, -1 if it is not allowed
+func GetReactionOrder(reaction string) int {
+	for index, allowed := range Reactions {
+		if allowed == reaction {
+			return index
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,235 @@
+package common
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRegex   = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+	boldRegex   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	italicRegex = regexp.MustCompile(`\*([^*]+)\*`)
+	// underscores are only treated as italic markers at word boundaries (not in snake_case)
+	underscoreItalicRegex = regexp.MustCompile(`(^|[^\w])_([^_]+)_([^\w]|$)`)
+	// mentionRegex matches @username, usernames follow the rules used for the registration
+	mentionRegex = regexp.MustCompile(`(^|[^\w@/.])@([a-zA-Z][a-zA-Z0-9_.]*[a-zA-Z0-9_])`)
+
+	// tagRegex matches the HTML tags in the rendered text (attributes are double quoted)
+	tagRegex  = regexp.MustCompile(`<(/?)([a-zA-Z]+)((?:\s+[a-zA-Z-]+="[^"<>]*")*)\s*/?>`)
+	attrRegex = regexp.MustCompile(`([a-zA-Z-]+)="([^"<>]*)"`)
+
+	// allowedTags are the HTML tags allowed by SanitizeHTML, mapped to their allowed attributes
+	allowedTags = map[string][]string{
+		"strong": nil,
+		"em":     nil,
+		"br":     nil,
+		"a":      {"href"},
+	}
+	// allowedURLSchemes are the schemes allowed in links, relative links are allowed as well
+	allowedURLSchemes = map[string]bool{
+		"http":   true,
+		"https":  true,
+		"mailto": true,
+	}
+)
+
+// RenderRichText renders the raw text using a Markdown subset and returns sanitized HTML
+// Supported syntax: **bold**, *italic* or _italic_, [label](url) and line breaks
+// mentions optionally maps the lower-cased usernames that can be mentioned to their links,
+// @username mentions of other users are left as plain text
+func RenderRichText(text string, mentions ...map[string]string) template.HTML {
+	mentionLinks := make(map[string]string)
+	for _, mentionMap := range mentions {
+		for username, link := range mentionMap {
+			mentionLinks[username] = link
+		}
+	}
+
+	text = strings.Replace(text, "\r\n", "\n", -1)
+
+	lines := strings.Split(text, "\n")
+	for index, line := range lines {
+		lines[index] = renderLine(line, mentionLinks)
+	}
+
+	return template.HTML(SanitizeHTML(strings.Join(lines, "<br>")))
+}
+
+// ExtractMentions returns the unique lower-cased usernames mentioned in the text
+func ExtractMentions(text string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		username := strings.ToLower(match[2])
+		if seen[username] {
+			continue
+		}
+
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+
+	return usernames
+}
+
+// renderLine renders the Markdown subset of a single line
+// The line is escaped before the Markdown syntax is converted, so no HTML in the input survives
+func renderLine(line string, mentionLinks map[string]string) string {
+	var rendered strings.Builder
+
+	start := 0
+	for _, match := range linkRegex.FindAllStringSubmatchIndex(line, -1) {
+		rendered.WriteString(renderMentions(renderEmphasis(html.EscapeString(line[start:match[0]])), mentionLinks))
+
+		label := renderEmphasis(html.EscapeString(line[match[2]:match[3]]))
+		href := line[match[4]:match[5]]
+		if isSafeURL(href) {
+			rendered.WriteString(`<a href="` + html.EscapeString(href) + `">` + label + `</a>`)
+		} else {
+			rendered.WriteString(html.EscapeString(line[match[0]:match[1]]))
+		}
+
+		start = match[1]
+	}
+	rendered.WriteString(renderMentions(renderEmphasis(html.EscapeString(line[start:])), mentionLinks))
+
+	return rendered.String()
+}
+
+// renderMentions converts the mentions of the known usernames into links
+func renderMentions(text string, mentionLinks map[string]string) string {
+	if len(mentionLinks) == 0 {
+		return text
+	}
+
+	return mentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+		match := mentionRegex.FindStringSubmatch(mention)
+		link, present := mentionLinks[strings.ToLower(match[2])]
+		if !present || !isSafeURL(link) {
+			return mention
+		}
+
+		return match[1] + `<a href="` + html.EscapeString(link) + `">@` + match[2] + `</a>`
+	})
+}
+
+// renderEmphasis converts the bold and italic markers of escaped text into HTML
+func renderEmphasis(text string) string {
+	text = boldRegex.ReplaceAllString(text, "<strong>$1</strong>")
+	text = italicRegex.ReplaceAllString(text, "<em>$1</em>")
+	return underscoreItalicRegex.ReplaceAllString(text, "$1<em>$2</em>$3")
+}
+
+// isSafeURL checks whether the URL is relative or uses one of the allowed schemes
+// The checks are done on the unescaped URL, which is what the browser follows
+func isSafeURL(rawURL string) bool {
+	unescapedURL := html.UnescapeString(rawURL)
+
+	// Browsers treat '\' as '/' and drop tabs and newlines, which turns '/\host' into '//host'
+	if strings.ContainsAny(unescapedURL, "\\\t\r\n") {
+		return false
+	}
+
+	parsedURL, err := url.Parse(unescapedURL)
+	if err != nil {
+		return false
+	}
+
+	if parsedURL.Scheme == "" {
+		// relative links within the app only
+		return parsedURL.Host == "" && strings.HasPrefix(unescapedURL, "/") && !strings.HasPrefix(unescapedURL, "//")
+	}
+
+	return allowedURLSchemes[strings.ToLower(parsedURL.Scheme)]
+}
+
+// SanitizeHTML keeps only the allowed tags and attributes of the HTML, everything else is escaped
+// Unclosed tags are closed and stray closing tags are dropped
+func SanitizeHTML(input string) string {
+	var sanitized strings.Builder
+	var openTags []string
+
+	start := 0
+	for _, match := range tagRegex.FindAllStringSubmatchIndex(input, -1) {
+		sanitized.WriteString(escapeText(input[start:match[0]]))
+		start = match[1]
+
+		closing := match[3] > match[2]
+		tagName := strings.ToLower(input[match[4]:match[5]])
+		allowedAttrs, allowed := allowedTags[tagName]
+		if !allowed {
+			sanitized.WriteString(html.EscapeString(input[match[0]:match[1]]))
+			continue
+		}
+
+		if tagName == "br" {
+			sanitized.WriteString("<br>")
+			continue
+		}
+
+		if closing {
+			// Close the tag only if it is open, along with any tags opened after it
+			for index := len(openTags) - 1; index >= 0; index-- {
+				if openTags[index] != tagName {
+					continue
+				}
+				for len(openTags) > index {
+					sanitized.WriteString("</" + openTags[len(openTags)-1] + ">")
+					openTags = openTags[:len(openTags)-1]
+				}
+				break
+			}
+			continue
+		}
+
+		external := false
+		sanitized.WriteString("<" + tagName)
+		for _, attr := range attrRegex.FindAllStringSubmatch(input[match[6]:match[7]], -1) {
+			attrName := strings.ToLower(attr[1])
+			if !containsString(allowedAttrs, attrName) {
+				continue
+			}
+			if attrName == "href" {
+				if !isSafeURL(attr[2]) {
+					continue
+				}
+				external = !strings.HasPrefix(html.UnescapeString(attr[2]), "/")
+			}
+			sanitized.WriteString(" " + attrName + `="` + attr[2] + `"`)
+		}
+		if external {
+			// links to other sites are opened in a new tab
+			sanitized.WriteString(` rel="nofollow noopener noreferrer" target="_blank"`)
+		}
+		sanitized.WriteString(">")
+		openTags = append(openTags, tagName)
+	}
+	sanitized.WriteString(escapeText(input[start:]))
+
+	for index := len(openTags) - 1; index >= 0; index-- {
+		sanitized.WriteString("</" + openTags[index] + ">")
+	}
+
+	return sanitized.String()
+}
+
+// escapeText escapes the angle brackets left in the text between the tags
+// Entities are left as they are since the text is already escaped
+func escapeText(text string) string {
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// containsString checks whether the string is present in the list
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
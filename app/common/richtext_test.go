@@ -0,0 +1,148 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderRichText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain text", "hello world", "hello world"},
+		{"bold", "**bold**", "<strong>bold</strong>"},
+		{"italic", "*italic* and _italic_", "<em>italic</em> and <em>italic</em>"},
+		{"snake case", "snake_case_name", "snake_case_name"},
+		{"line breaks", "one\r\ntwo\nthree", "one<br>two<br>three"},
+		{"relative link", "[home](/home)", `<a href="/home">home</a>`},
+		{"external link", "[site](https://example.com)",
+			`<a href="https://example.com" rel="nofollow noopener noreferrer" target="_blank">site</a>`},
+		{"mail link", "[mail](mailto:user@example.com)",
+			`<a href="mailto:user@example.com" rel="nofollow noopener noreferrer" target="_blank">mail</a>`},
+		{"bold link label", "[**home**](/home)", `<a href="/home"><strong>home</strong></a>`},
+		{"html is escaped", "<b>bold</b>", "&lt;b&gt;bold&lt;/b&gt;"},
+		{"script is escaped", `<script>alert("x")</script>`, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+	}
+
+	for _, test := range tests {
+		got := string(RenderRichText(test.input))
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRenderRichTextUnsafeLinks(t *testing.T) {
+	tests := []string{
+		"[x](javascript:alert(1))",
+		"[x](JaVaScRiPt:alert`1`)",
+		"[x](java&#115;cript:alert`1`)",
+		"[x](&#106;avascript:alert`1`)",
+		"[x](data:text/html;base64,PHNjcmlwdD4=)",
+		"[x](vbscript:msgbox)",
+		"[x](//evil.com)",
+		"[x](/&#47;evil.com)",
+		"[x](/&sol;evil.com)",
+		`[x](/\evil.com)`,
+		`[x](\\evil.com)`,
+		"[x](relative/path)",
+		"[x](evil.com)",
+	}
+
+	for _, input := range tests {
+		got := string(RenderRichText(input))
+		if strings.Contains(got, "<a") {
+			t.Errorf("%q: the unsafe link was rendered - %q", input, got)
+		}
+	}
+}
+
+func TestRenderRichTextMentions(t *testing.T) {
+	mentions := map[string]string{
+		"alice": "/users/alice",
+		"eve":   "javascript:alert(1)",
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"known user", "hi @Alice!", `hi <a href="/users/alice">@Alice</a>!`},
+		{"unknown user", "hi @bob", "hi @bob"},
+		{"unsafe link", "hi @eve", "hi @eve"},
+		{"email address", "mail alice@example.com", "mail alice@example.com"},
+	}
+
+	for _, test := range tests {
+		got := string(RenderRichText(test.input, mentions))
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"allowed tags", "<strong>a</strong><em>b</em><br/>", "<strong>a</strong><em>b</em><br>"},
+		{"upper case tags", "<STRONG>a</STRONG>", "<strong>a</strong>"},
+		{"unclosed tags", "<strong><em>a", "<strong><em>a</em></strong>"},
+		{"stray closing tag", "a</strong>", "a"},
+		{"overlapping tags", "<strong><em>a</strong>b</em>", "<strong><em>a</em></strong>b"},
+		{"disallowed tag", "<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"disallowed attribute", `<a href="/home" onclick="alert(1)">a</a>`, `<a href="/home">a</a>`},
+		{"attribute on a plain tag", `<strong style="color:red">a</strong>`, "<strong>a</strong>"},
+		{"unquoted attributes", "<img src=x onerror=alert(1)>", "&lt;img src=x onerror=alert(1)&gt;"},
+		{"external link", `<a href="http://example.com">a</a>`,
+			`<a href="http://example.com" rel="nofollow noopener noreferrer" target="_blank">a</a>`},
+		{"javascript link", `<a href="javascript:alert(1)">a</a>`, "<a>a</a>"},
+		{"encoded javascript link", `<a href="&#106;avascript:alert(1)">a</a>`, "<a>a</a>"},
+		{"protocol relative link", `<a href="//evil.com">a</a>`, "<a>a</a>"},
+		{"encoded protocol relative link", `<a href="/&#47;evil.com">a</a>`, "<a>a</a>"},
+		{"backslash link", `<a href="/\evil.com">a</a>`, "<a>a</a>"},
+		{"tab in link", "<a href=\"/\t/evil.com\">a</a>", "<a>a</a>"},
+		{"entities are kept", "a &amp; b &lt;c&gt;", "a &amp; b &lt;c&gt;"},
+	}
+
+	for _, test := range tests {
+		got := SanitizeHTML(test.input)
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"no mentions", "hello world", nil},
+		{"single mention", "hi @alice", []string{"alice"}},
+		{"start of the text", "@alice hi", []string{"alice"}},
+		{"lower-cased and unique", "@Alice @alice @ALICE", []string{"alice"}},
+		{"order of mentions", "@bob, @alice and @carol.", []string{"bob", "alice", "carol"}},
+		{"dots and underscores", "@first.last @snake_case", []string{"first.last", "snake_case"}},
+		{"trailing dot", "thanks @alice.", []string{"alice"}},
+		{"within markup", "**@alice** (@bob)", []string{"alice", "bob"}},
+		{"email address", "alice@example.com", nil},
+		{"url", "https://example.com/@alice", nil},
+		{"double at", "@@alice", nil},
+		{"starts with a number", "@1alice", nil},
+		{"single character", "@a", nil},
+	}
+
+	for _, test := range tests {
+		got := ExtractMentions(test.input)
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package common
+
+// groupRolePermissions is the permission matrix of the group roles
+// Global admins are allowed every action irrespective of the matrix
+var groupRolePermissions = map[GroupRole][]GroupPermission{
+	GroupRoleViewer: {},
+	GroupRoleContributor: {
+		PermissionUpload,
+		PermissionComment,
+		PermissionDeleteOwnItems,
+	},
+	GroupRoleModerator: {
+		PermissionUpload,
+		PermissionComment,
+		PermissionDeleteOwnItems,
+		PermissionDeleteOthersItems,
+	},
+	GroupRoleLeader: {
+		PermissionUpload,
+		PermissionComment,
+		PermissionDeleteOwnItems,
+		PermissionDeleteOthersItems,
+		PermissionManageMembers,
+		PermissionManageSettings,
+		PermissionApproveItems,
+	},
+}
+
+// Can checks whether the role allows the action
+func (r GroupRole) Can(permission GroupPermission) bool {
+	for _, allowed := range groupRolePermissions[r] {
+		if allowed == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValid checks whether the role is one of the roles of the members
+func (r GroupRole) IsValid() bool {
+	_, ok := groupRolePermissions[r]
+	return ok
+}
+
+// IsWrite checks whether the permission changes the content of the group, these are denied in the archived groups
+func (p GroupPermission) IsWrite() bool {
+	switch p {
+	case PermissionUpload, PermissionComment, PermissionDeleteOwnItems, PermissionDeleteOthersItems:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package common
+
+import "testing"
+
+func TestGroupRoleCan(t *testing.T) {
+	permissions := []GroupPermission{
+		PermissionUpload,
+		PermissionComment,
+		PermissionDeleteOwnItems,
+		PermissionDeleteOthersItems,
+		PermissionManageMembers,
+		PermissionManageSettings,
+		PermissionApproveItems,
+	}
+
+	// allowed lists the permissions of each role, in the order of the permissions above
+	allowed := map[GroupRole][]bool{
+		GroupRoleNone:        {false, false, false, false, false, false, false},
+		GroupRoleViewer:      {false, false, false, false, false, false, false},
+		GroupRoleContributor: {true, true, true, false, false, false, false},
+		GroupRoleModerator:   {true, true, true, true, false, false, false},
+		GroupRoleLeader:      {true, true, true, true, true, true, true},
+		GroupRole(99):        {false, false, false, false, false, false, false},
+	}
+
+	for role, expected := range allowed {
+		for index, permission := range permissions {
+			if got := role.Can(permission); got != expected[index] {
+				t.Errorf("role %d, permission %d: got %t, want %t", role, permission, got, expected[index])
+			}
+		}
+		if role.Can(GroupPermission(0)) {
+			t.Errorf("role %d: an unknown permission is allowed", role)
+		}
+	}
+}
+
+func TestGroupRoleIsValid(t *testing.T) {
+	tests := map[GroupRole]bool{
+		GroupRoleNone:        false,
+		GroupRoleViewer:      true,
+		GroupRoleContributor: true,
+		GroupRoleModerator:   true,
+		GroupRoleLeader:      true,
+		GroupRole(99):        false,
+	}
+
+	for role, want := range tests {
+		if got := role.IsValid(); got != want {
+			t.Errorf("role %d: got %t, want %t", role, got, want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package common
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"strconv"
 )
@@ -26,3 +28,14 @@ func SHA256(str string) string {
 	h.Write([]byte(str))
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
+
+// RandomToken returns a URL safe random token generated from the given number of random bytes
+func RandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"fmt"
 	"log"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -83,8 +85,19 @@ func (c Account) Login(username, password string) revel.Result {
 }
 
 // Register is the GET action for user signup page
-func (c Account) Register() revel.Result {
-	return c.Render()
+// invite is the token of a group invitation, the user joins the group once registered
+func (c Account) Register(invite string) revel.Result {
+	if invite != "" {
+		invitation, err := models.GetInvitationByToken(c.Log, invite)
+		if err != nil || invitation.UserID > 0 ||
+			invitation.Status != common.InvitationPending.GetStatusID() || invitation.IsExpired() {
+			c.Flash.Error("The invitation is no longer valid. You can still register an account")
+			return c.Redirect(Account.Register)
+		}
+		c.ViewArgs["inviteEmail"] = invitation.Email
+	}
+
+	return c.Render(invite)
 }
 
 // Add is the POST method for user signup
@@ -95,8 +108,14 @@ func (c Account) Add() revel.Result {
 	username := c.Params.Get("username")
 	password := c.Params.Get("password")
 	confirmPassword := c.Params.Get("confirm_password")
+	invite := c.Params.Get("invite")
 	username = strings.ToLower(username)
 
+	registerURL := "/register"
+	if invite != "" {
+		registerURL = fmt.Sprintf("/register?invite=%s", url.QueryEscape(invite))
+	}
+
 	c.Validation.Required(firstName).Message("First Name is required")
 	c.Validation.Required(lastName).Message("Last Name is required")
 	c.Validation.Required(email).Message("Email is required")
@@ -118,7 +137,7 @@ func (c Account) Add() revel.Result {
 		// Store the validation errors in the flash context and redirect.
 		c.Validation.Keep()
 		c.FlashParams()
-		return c.Redirect(Account.Register)
+		return c.Redirect(registerURL)
 	}
 
 	if !strings.ContainsAny(password, lowercase) ||
@@ -126,19 +145,19 @@ func (c Account) Add() revel.Result {
 		!strings.ContainsAny(password, digits) ||
 		!strings.ContainsAny(password, specialChars) {
 		c.Flash.Error("Invalid password. Must contain at least one - upper case letter, lowercase letter, digit, and special character (!@#$)")
-		return c.Redirect(Account.Register)
+		return c.Redirect(registerURL)
 	}
 
 	if password != confirmPassword {
 		c.Flash.Error("Passwords do not match")
-		return c.Redirect(Account.Register)
+		return c.Redirect(registerURL)
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword(
 		[]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		c.Flash.Error("Unable to add the user at the moment")
-		return c.Redirect(Account.Register)
+		return c.Redirect(registerURL)
 	}
 
 	user := &models.User{
@@ -156,7 +175,11 @@ func (c Account) Add() revel.Result {
 		!added {
 		log.Printf("Error while adding user. Err: %+v", err)
 		c.Flash.Error("Unable to add user at the moment. Please try after some time.")
-		return c.Redirect(Account.Register)
+		return c.Redirect(registerURL)
+	}
+
+	if invite != "" {
+		c.acceptInvitation(invite, username)
 	}
 
 	c.Flash.Success("Please login to continue")
@@ -170,3 +193,25 @@ func (c Account) Logout() revel.Result {
 	}
 	return c.Redirect(Account.Index)
 }
+
+// acceptInvitation adds the newly registered user to the group of the email invitation
+// The account is already created, so failures only flash an error
+func (c Account) acceptInvitation(token, username string) {
+	user, err := models.GetUserByUserName(username)
+	if err != nil {
+		c.Log.Errorf("Unable to get the registered user - %s. Err: %+v", username, err)
+		c.Flash.Error("Unable to join the group of the invitation")
+		return
+	}
+
+	invitation, err := models.GetInvitationByToken(c.Log, token)
+	if err != nil || invitation.UserID > 0 {
+		c.Flash.Error("The invitation is no longer valid")
+		return
+	}
+
+	err = invitation.Accept(c.Log, user.UserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/models"
+)
+
+// Activity is the controller for the activity feeds of the groups
+type Activity struct {
+	*revel.Controller
+}
+
+// Index is the GET action for the activity across all the groups, available to the admins
+// before is the NextCursor of the previous page, 0 for the first page
+func (c Activity) Index(before int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Authz check
+	userModel, err := models.GetUserByUserID(intUserID)
+	if err != nil {
+		c.Flash.Error("Unable to access user details")
+		return c.Redirect(Account.Index)
+	}
+
+	if !userModel.IsAdmin {
+		return c.Redirect(Account.Unauthorized)
+	}
+
+	activity, err := models.GetGroupActivity(c.Log, nil, before)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		activity = &models.GroupActivity{}
+	}
+
+	// The global activity uses the same view as the activity of a group
+	c.ViewArgs["activity"] = activity
+	return c.RenderTemplate("Activity/Group.html")
+}
+
+// Group is the GET action for the activity feed of a group, available to the members of the group
+// before is the NextCursor of the previous page, 0 for the first page
+func (c Activity) Group(id, before int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to get the activity of the group")
+		return c.Redirect(Home.Index)
+	}
+
+	exists, groupName := checkIfGroupIDExists(groups, id)
+	if !exists {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+		return c.Redirect(Home.Index)
+	}
+
+	activity, err := models.GetGroupActivity(c.Log, []int64{id}, before)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", id)
+	}
+	activity.GroupID = id
+	activity.GroupName = groupName
+
+	return c.Render(activity)
+}
@@ -0,0 +1,529 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/revel/revel"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/models"
+)
+
+var albumNameRegex = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.' -]*$")
+
+// Album is the controller for the albums within a group
+type Album struct {
+	*revel.Controller
+}
+
+// Details is the GET action for the album page
+func (c Album) Details(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	album, groupName, err := getAlbumForUser(c.Log, intUserID, id, false)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	items, err := models.GetAlbumItems(c.Log, album.AlbumID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	albumDetails := &models.AlbumDetails{
+		Album:     album,
+		GroupName: groupName,
+		Items:     items,
+	}
+
+	// Public share links of the album, for the creator of the album and the leaders of the group
+	canManageLinks, err := canManageShareLinks(c.Log, intUserID, album.CreatedBy, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+	if canManageLinks {
+		shareLinks, err := models.GetShareLinks(c.Log, 0, album.AlbumID)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		albumDetails.ShareLinks = shareLinks
+	}
+
+	return c.Render(albumDetails)
+}
+
+// Create is the action method for creating an album in a group
+func (c Album) Create(groupID, name string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intGroupID, err := strconv.ParseInt(groupID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Group ID found - %s. Error: %s", groupID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Group.Index)
+	}
+
+	c.Validation.Required(name).Message("Album name is required")
+	c.Validation.MaxSize(name, 60).Message("Album name should be less than 60 characters")
+	c.Validation.Match(name, albumNameRegex).Message("Album name should start with an alphabet or a number and must include only alphabets (a-z, A-Z), numbers (0-9), spaces and symbols (. ' - and _)")
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect("/groups/%d", intGroupID)
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to create the album")
+		return c.Redirect("/groups/%d", intGroupID)
+	}
+
+	isMember, _ := checkIfGroupMember(groups, intGroupID)
+	if !isMember {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to create albums in the group")
+		return c.Redirect(Group.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, intGroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", intGroupID)
+	}
+
+	canUpload, err := hasGroupPermission(c.Log, intUserID, intGroupID, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", intGroupID)
+	}
+	if !canUpload {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow creating albums.")
+		return c.Redirect("/groups/%d", intGroupID)
+	}
+
+	album := &models.Album{
+		AlbumName: name,
+		GroupID:   intGroupID,
+		CreatedBy: intUserID,
+	}
+
+	err = album.Add(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", intGroupID)
+	}
+
+	c.Flash.Success("Album created successfully")
+	return c.Redirect("/albums/%d", album.AlbumID)
+}
+
+// Rename is the action method for renaming an album
+func (c Album) Rename(albumID, name string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intAlbumID, err := strconv.ParseInt(albumID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Album ID found - %s. Error: %s", albumID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Group.Index)
+	}
+
+	c.Validation.Required(name).Message("Album name is required")
+	c.Validation.MaxSize(name, 60).Message("Album name should be less than 60 characters")
+	c.Validation.Match(name, albumNameRegex).Message("Album name should start with an alphabet or a number and must include only alphabets (a-z, A-Z), numbers (0-9), spaces and symbols (. ' - and _)")
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	album, _, err := getAlbumForUser(c.Log, intUserID, intAlbumID, true)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	canEdit, err := canEditAlbum(c.Log, intUserID, album)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+	if !canEdit {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow changing the album.")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	album.AlbumName = name
+	err = album.Rename(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	c.Flash.Success("Album renamed successfully")
+	return c.Redirect("/albums/%d", intAlbumID)
+}
+
+// Move is the action method for moving an album up or down in the group's album list
+func (c Album) Move(albumID, direction string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intAlbumID, err := strconv.ParseInt(albumID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Album ID found - %s. Error: %s", albumID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Group.Index)
+	}
+
+	album, _, err := getAlbumForUser(c.Log, intUserID, intAlbumID, true)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", album.GroupID)
+	}
+
+	canEdit, err := canEditAlbum(c.Log, intUserID, album)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", album.GroupID)
+	}
+	if !canEdit {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow changing the album.")
+		return c.Redirect("/groups/%d", album.GroupID)
+	}
+
+	err = album.MoveAlbum(c.Log, direction == "up")
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect("/groups/%d", album.GroupID)
+}
+
+// SetCover is the action method for setting the cover item of an album
+func (c Album) SetCover(albumID, itemID string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intAlbumID, err := strconv.ParseInt(albumID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Album ID found - %s. Error: %s", albumID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Group.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	album, _, err := getAlbumForUser(c.Log, intUserID, intAlbumID, true)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	canEdit, err := canEditAlbum(c.Log, intUserID, album)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+	if !canEdit {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow changing the album.")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	err = album.SetCover(c.Log, intItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	c.Flash.Success("Album cover updated")
+	return c.Redirect("/albums/%d", intAlbumID)
+}
+
+// AddItem is the action method for adding an item to an album
+func (c Album) AddItem(albumID, itemID string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Home.Index)
+	}
+
+	intAlbumID, err := strconv.ParseInt(albumID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Album ID found - %s. Error: %s", albumID, err.Error())
+		c.Flash.Error("Please select an album")
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	album, _, err := getAlbumForUser(c.Log, intUserID, intAlbumID, true)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	err = checkGroupNotArchived(c.Log, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	canUpload, err := hasGroupPermission(c.Log, intUserID, album.GroupID, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+	if !canUpload {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow adding items to the album.")
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	itemMeta, err := models.GetItemDetailsByID(c.Log, intItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	err = album.AddItem(c.Log, itemMeta, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	c.Flash.Success("Item added to the album '%s'", album.AlbumName)
+	return c.Redirect("/item/%d?album=%d", intItemID, intAlbumID)
+}
+
+// RemoveItem is the action method for removing an item from an album
+func (c Album) RemoveItem(albumID, itemID string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intAlbumID, err := strconv.ParseInt(albumID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Album ID found - %s. Error: %s", albumID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Group.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	album, _, err := getAlbumForUser(c.Log, intUserID, intAlbumID, true)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	canEdit, err := canEditAlbum(c.Log, intUserID, album)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+	if !canEdit {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow changing the album.")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	err = album.RemoveItem(c.Log, intItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	c.Flash.Success("Item removed from the album")
+	return c.Redirect("/albums/%d", intAlbumID)
+}
+
+// MoveItem is the action method for moving an item up or down within an album
+func (c Album) MoveItem(albumID, itemID, direction string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intAlbumID, err := strconv.ParseInt(albumID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid Album ID found - %s. Error: %s", albumID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect(Group.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to process the request")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	album, _, err := getAlbumForUser(c.Log, intUserID, intAlbumID, true)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, album.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	canEdit, err := canEditAlbum(c.Log, intUserID, album)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+	if !canEdit {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow changing the album.")
+		return c.Redirect("/albums/%d", intAlbumID)
+	}
+
+	err = album.MoveItem(c.Log, intItemID, direction == "up")
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect("/albums/%d", intAlbumID)
+}
+
+// getAlbumForUser returns the album along with its group name if the user has access to the album's group
+// memberOnly is set for the changes to the album, which the visitors of a public group cannot make
+func getAlbumForUser(log logger.MultiLogger, userID, albumID int64, memberOnly bool) (*models.Album, string, error) {
+	album, err := models.GetAlbumByID(log, albumID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(userID)
+	if err != nil {
+		log.Errorf("Unable to get the groups for user - %d. Error: %s", userID, err.Error())
+		return nil, "", fmt.Errorf("Unable to get the details of the album")
+	}
+
+	exists, groupName := checkIfGroupIDExists(groups, album.GroupID)
+	if memberOnly {
+		exists, groupName = checkIfGroupMember(groups, album.GroupID)
+	}
+	if !exists {
+		return nil, "", fmt.Errorf("Unauthorized! You do not have enough permissions to access the album")
+	}
+
+	return album, groupName, nil
+}
+
+// canEditAlbum checks whether the user can make changes to the album
+// The albums of the other users can be changed only by the moderators of the group, as with the items
+func canEditAlbum(log logger.MultiLogger, userID int64, album *models.Album) (bool, error) {
+	if album.CreatedBy == userID {
+		return hasGroupPermission(log, userID, album.GroupID, common.PermissionUpload)
+	}
+	return hasGroupPermission(log, userID, album.GroupID, common.PermissionDeleteOthersItems)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/models"
+)
+
+// Favorite is the controller for the items starred by the logged in user
+type Favorite struct {
+	*revel.Controller
+}
+
+// Index is the GET action for the favorites page
+func (c Favorite) Index() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	favorites, err := models.GetFavoritesForUser(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Render(favorites)
+}
+
+// Toggle stars the given item for the logged in user, or removes the star if the item is already starred
+func (c Favorite) Toggle(itemID string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to update the favorites")
+		return c.Redirect(Favorite.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, intItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Favorite.Index)
+	}
+
+	// Check if user has access to the item
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to update the favorites")
+		return c.Redirect("/item/%d", intItemID)
+	}
+	_, hasAccess, err := models.GetItemAccessGroup(c.Log, groups, itemMeta, 0)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+	if !hasAccess {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+		return c.Redirect(Home.Index)
+	}
+
+	favorite := &models.Favorite{
+		UserID: intUserID,
+		ItemID: intItemID,
+	}
+	err = favorite.ToggleFavorite(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect("/item/%d", intItemID)
+}
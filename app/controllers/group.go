@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"fmt"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/revel/revel"
+	"github.com/revel/revel/logger"
 	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/models"
 )
@@ -28,11 +31,33 @@ func (c Group) Index() revel.Result {
 		return c.Redirect(Account.Index)
 	}
 
-	// Get all the groups applicable to user
+	// Get all the groups applicable to user, the subgroups are listed under their parent group
 	groups, err := models.GetAllGroups(intUserID)
 	if err != nil {
 		c.Log.Errorf("Unable to fetch list of groups. Error: %s", err.Error())
 	}
+	groupTree := models.BuildGroupTree(groups)
+
+	return c.Render(groupTree)
+}
+
+// Directory is the GET action for browsing the public and listed groups
+func (c Group) Directory() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	groups, err := models.GetGroupDirectory(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
 
 	return c.Render(groups)
 }
@@ -49,9 +74,7 @@ func (c Group) Create(group string) revel.Result {
 		c.Flash.Error("Please login to continue")
 		return c.Redirect(Account.Index)
 	}
-	c.Validation.Required(group).Message("Group name is required")
-	c.Validation.MaxSize(group, 60).Message("Group name should be less than 60 characters")
-	c.Validation.Match(group, regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_.' ]+$")).Message("Group name should start with an alphabet and must include only alphabets (a-z, A-Z), numbers (0-9) and symbols (. and _)")
+	c.validateGroupName(group)
 	if c.Validation.HasErrors() {
 		// Store the validation errors in the flash context and redirect.
 		c.Validation.Keep()
@@ -77,8 +100,8 @@ func (c Group) Create(group string) revel.Result {
 	return c.Redirect(Group.Index)
 }
 
-// Details displays the details of a group ID
-func (c Group) Details(id int64) revel.Result {
+// Rename is the POST action for changing the name of the group
+func (c Group) Rename(groupID int64, group string) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
@@ -90,22 +113,82 @@ func (c Group) Details(id int64) revel.Result {
 		return c.Redirect(Account.Index)
 	}
 
-	group, err := models.GetGroupDetails(c.Log, intUserID, id)
+	canManage, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionManageSettings)
 	if err != nil {
-		c.Log.Errorf("Unable to fetch group details. Error: %s", err.Error())
-		c.Flash.Error("Internal error. Please try after sometime.")
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to rename the group.")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.validateGroupName(group)
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	groupModel := &models.Group{
+		GroupID: groupID,
+	}
+	err = groupModel.Rename(c.Log, group, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
 	}
 
-	return c.Render(group)
+	c.Flash.Success("Group renamed successfully")
+	return c.Redirect("/groups/%d", groupID)
 }
 
-// MapUser maps a user with the given username to the group
-func (c Group) MapUser(username, groupID string) revel.Result {
+// Archive is the POST action for archiving the group (archived=true) or restoring an archived group
+func (c Group) Archive(groupID int64, archived bool) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
 
-	username = strings.ToLower(username)
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionManageSettings)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to archive the group.")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	groupModel := &models.Group{
+		GroupID: groupID,
+	}
+	err = groupModel.SetArchived(c.Log, archived, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	if archived {
+		c.Flash.Success("Group archived. It is read-only until it is restored")
+	} else {
+		c.Flash.Success("Group restored")
+	}
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// UpdateVisibility is the POST action for changing who can discover and read the group
+func (c Group) UpdateVisibility(groupID int64, visibility int) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
 
 	intUserID, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
@@ -114,45 +197,338 @@ func (c Group) MapUser(username, groupID string) revel.Result {
 		return c.Redirect(Account.Index)
 	}
 
-	intGroupID, err := strconv.ParseInt(groupID, 10, 64)
+	canManage, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionManageSettings)
 	if err != nil {
-		c.Log.Errorf("Invalid Group ID found - %s. Error: %s", groupID, err.Error())
-		c.Flash.Error("Unable to process the request")
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to change the visibility of the group.")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	groupModel := &models.Group{
+		GroupID: groupID,
+	}
+	err = groupModel.SetVisibility(c.Log, common.GroupVisibility(visibility), intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.Flash.Success("The group is now %s", strings.ToLower(common.GroupVisibility(visibility).GetString()))
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// UpdateParent is the POST action for moving the group under another group, or to the top level if parentGroupID is 0
+// inheritMembers lets the members of the parent group read the group
+func (c Group) UpdateParent(groupID, parentGroupID int64, inheritMembers bool) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionManageSettings)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to move the group.")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	// The group is moved under the groups the user manages as well
+	if parentGroupID > 0 {
+		canManage, err = hasGroupPermission(c.Log, intUserID, parentGroupID, common.PermissionManageSettings)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect("/groups/%d", groupID)
+		}
+		if !canManage {
+			c.Flash.Error("Unauthorized. You do not have enough permissions to add subgroups to the parent group.")
+			return c.Redirect("/groups/%d", groupID)
+		}
+	}
+
+	groupModel := &models.Group{
+		GroupID: groupID,
+	}
+	err = groupModel.SetParent(c.Log, parentGroupID, inheritMembers, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.Flash.Success("Parent group updated")
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// Settings is the GET action for the settings page of the group, for the leaders of the group
+func (c Group) Settings(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intUserID, id, common.PermissionManageSettings)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", id)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. Only the group leaders can manage the settings of the group.")
+		return c.Redirect("/groups/%d", id)
+	}
+
+	group, err := models.GetGroupDetailUsingID(id)
+	if err != nil {
+		c.Log.Errorf("Unable to get the group - %d. Error: %s", id, err.Error())
+		c.Flash.Error("Group details not available")
 		return c.Redirect(Group.Index)
 	}
 
-	c.Validation.Required(username).Message("Username is required")
-	c.Validation.MaxSize(username, 12).Message("Username should be 12 characters or less")
-	c.Validation.Match(username, regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_.]+$")).Message("Item name should start with an alphabet and must include only alphabets (a-z, A-Z), numbers (0-9) and symbols (. and _)")
+	settings, err := models.GetGroupSettings(c.Log, id)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", id)
+	}
+	settings.GroupName = group.GroupName
 
-	if c.Validation.HasErrors() {
-		// Store the validation errors in the flash context and redirect.
-		c.Validation.Keep()
-		c.FlashParams()
-		return c.Redirect("/groups/%d", intGroupID)
+	return c.Render(settings)
+}
+
+// UpdateSettings is the POST action for saving the settings of the group
+// itemTypes are the item types allowed in the group
+func (c Group) UpdateSettings(groupID int64, itemTypes []int, commentsEnabled, uploadApproval bool, defaultSort string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
 	}
 
-	user, err := models.GetUserByUserName(username)
+	canManage, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionManageSettings)
 	if err != nil {
-		c.Log.Errorf("Invalid username - %s. Error: %+v", username, err)
-		c.Flash.Error("Invalid username provided")
-		return c.Redirect("/groups/%d", intGroupID)
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. Only the group leaders can manage the settings of the group.")
+		return c.Redirect("/groups/%d", groupID)
 	}
 
-	userGroupMap := &models.UserGroupMap{
-		UserID:    user.UserID,
-		GroupID:   intGroupID,
-		CreatedBy: intUserID,
+	settings := &models.GroupSettings{
+		GroupID:          groupID,
+		AllowedItemTypes: itemTypes,
+		CommentsEnabled:  commentsEnabled,
+		UploadApproval:   uploadApproval,
+		DefaultSort:      defaultSort,
+	}
+	err = settings.Update(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d/settings", groupID)
+	}
+
+	c.Flash.Success("Group settings updated successfully")
+	return c.Redirect("/groups/%d/settings", groupID)
+}
+
+// Delete is the POST action for an admin deleting the group along with all its content
+// The content is cleaned up in the background, the admin is taken to the progress of the deletion
+func (c Group) Delete(groupID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	user, err := models.GetUserByUserID(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to fetch user details from database. Error: %s", err.Error())
+		c.Flash.Error("Unable to fetch user details")
+		return c.Redirect(Home.Index)
+	}
+	if !user.IsAdmin {
+		return c.Redirect(Account.Unauthorized)
+	}
+
+	deletion, err := models.StartGroupDeletion(c.Log, groupID, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.Flash.Success("Deleting the group '%s'", deletion.GroupName)
+	return c.Redirect("/groups/deletions/%d", deletion.DeletionID)
+}
+
+// Deletions lists the group deletions with their progress to the admins
+func (c Group) Deletions() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	user, err := models.GetUserByUserID(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to fetch user details from database. Error: %s", err.Error())
+		c.Flash.Error("Unable to fetch user details")
+		return c.Redirect(Home.Index)
+	}
+	if !user.IsAdmin {
+		return c.Redirect(Account.Unauthorized)
 	}
 
-	err = userGroupMap.Add(c.Log, intUserID)
+	deletions, err := models.GetGroupDeletions(c.Log)
 	if err != nil {
 		c.Flash.Error(err.Error())
-		return c.Redirect("/groups/%d", intGroupID)
 	}
 
-	c.Flash.Success("Successfully tagged the user to the group")
-	return c.Redirect("/groups/%d", intGroupID)
+	// Keep refreshing the progress while any deletion is running
+	for _, deletion := range deletions {
+		if deletion.IsRunning() {
+			c.ViewArgs["refreshSeconds"] = common.GroupDeletionRefreshSeconds
+			break
+		}
+	}
+
+	return c.Render(deletions)
+}
+
+// Deletion displays the progress of a group deletion to the admins
+func (c Group) Deletion(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	user, err := models.GetUserByUserID(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to fetch user details from database. Error: %s", err.Error())
+		c.Flash.Error("Unable to fetch user details")
+		return c.Redirect(Home.Index)
+	}
+	if !user.IsAdmin {
+		return c.Redirect(Account.Unauthorized)
+	}
+
+	deletion, err := models.GetGroupDeletionByID(c.Log, id)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Deletions)
+	}
+
+	if deletion.IsRunning() {
+		c.ViewArgs["refreshSeconds"] = common.GroupDeletionRefreshSeconds
+	}
+
+	return c.Render(deletion)
+}
+
+// Details displays the details of a group ID along with a page of the group's items
+// sort is the order of the gallery, the default sort order of the group is used if it is empty
+// cursor is the NextCursor of the previous gallery page, empty for the first page
+func (c Group) Details(id int64, itemType int, uploader int64, sort, cursor string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	group, err := models.GetGroupDetails(c.Log, intUserID, id)
+	if err != nil {
+		c.Log.Errorf("Unable to fetch group details. Error: %s", err.Error())
+		c.Flash.Error("Internal error. Please try after sometime.")
+		return c.Render(group)
+	}
+
+	// The gallery is sorted in the default order of the group unless another order is picked
+	if sort == "" {
+		settings, err := models.GetGroupSettings(c.Log, id)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		} else {
+			sort = settings.DefaultSort
+		}
+	}
+
+	gallery, err := getGroupGallery(c.Log, intUserID, id, itemType, uploader, sort, cursor)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	// The subgroups the user can read, and the groups the leaders can move the group under
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+	} else {
+		group.Subgroups = models.GetSubgroups(groups, id)
+		if group.CanManageSettings() {
+			parentGroups, err := filterGroupsByPermission(c.Log, intUserID, groups, common.PermissionManageSettings)
+			if err != nil {
+				c.Flash.Error(err.Error())
+			}
+			group.ParentCandidates = models.GetParentCandidates(parentGroups, id)
+		}
+	}
+
+	if group.CanManageMembers() {
+		group.Invitations, err = models.GetInvitationsForGroup(c.Log, id)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+	}
+
+	// Join requests are handled by the members of the group only, admins do not see them
+	role, err := models.GetGroupRole(c.Log, intUserID, id)
+	if err == nil && role.Can(common.PermissionManageMembers) {
+		group.JoinRequests, err = models.GetPendingJoinRequestsForGroup(c.Log, id)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+	}
+
+	return c.Render(group, gallery)
 }
 
 // RequestLeadAccess creates a new request for a member to be promoted to group lead
@@ -189,3 +565,371 @@ func (c Group) RequestLeadAccess(groupID string) revel.Result {
 	c.Flash.Success("Successfully created a request for group leader access")
 	return c.Redirect(Group.Index)
 }
+
+// RemoveMember is the POST action for a leader removing a member from the group
+// items decides whether the items of the member are kept, transferred to the leader or deleted
+func (c Group) RemoveMember(groupID, userID int64, items string) revel.Result {
+	actorID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intActorID, err := strconv.ParseInt(actorID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", actorID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	if userID == intActorID {
+		return c.Leave(groupID, items)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intActorID, groupID, common.PermissionManageMembers)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to remove users from the group.")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	deletedItems, err := models.RemoveGroupMember(c.Log, groupID, userID, intActorID, items)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	removeItemFiles(c.Log, deletedItems)
+
+	c.Flash.Success("Successfully removed the user from the group")
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// UpdateRole is the POST action for a leader changing the role of a member of the group
+// role is one of viewer, contributor, moderator and leader, so the leaders can promote and demote members directly
+func (c Group) UpdateRole(groupID, userID int64, role int) revel.Result {
+	actorID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intActorID, err := strconv.ParseInt(actorID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", actorID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intActorID, groupID, common.PermissionManageMembers)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to change the roles in the group.")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	userGroupMap := &models.UserGroupMap{
+		UserID:  userID,
+		GroupID: groupID,
+	}
+	err = userGroupMap.UpdateRole(c.Log, common.GroupRole(role), intActorID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.Flash.Success("Successfully updated the role of the user")
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// TransferOwnership is the POST action for the owner of the group handing it over to another member
+func (c Group) TransferOwnership(groupID, userID int64) revel.Result {
+	actorID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intActorID, err := strconv.ParseInt(actorID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", actorID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Only the owner of the group and the admins can transfer the ownership
+	group, err := models.GetGroupDetailUsingID(groupID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the group - %d. Error: %s", groupID, err.Error())
+		c.Flash.Error("The group does not exist")
+		return c.Redirect(Group.Index)
+	}
+	if group.CreatedBy != intActorID {
+		user, err := models.GetUserByUserID(intActorID)
+		if err != nil {
+			c.Log.Errorf("Unable to fetch user details from database. Error: %s", err.Error())
+			c.Flash.Error("Unable to fetch user details")
+			return c.Redirect("/groups/%d", groupID)
+		}
+		if !user.IsAdmin {
+			c.Flash.Error("Unauthorized. Only the owner of the group can transfer its ownership.")
+			return c.Redirect("/groups/%d", groupID)
+		}
+	}
+
+	err = group.TransferOwnership(c.Log, userID, intActorID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.Flash.Success("Successfully transferred the ownership of the group")
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// Leave is the POST action for the logged in user leaving the group
+// items decides whether the items of the user are kept, transferred to a leader or deleted
+func (c Group) Leave(groupID int64, items string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	deletedItems, err := models.RemoveGroupMember(c.Log, groupID, intUserID, intUserID, items)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	removeItemFiles(c.Log, deletedItems)
+
+	c.Flash.Success("You have left the group")
+	return c.Redirect(Group.Index)
+}
+
+// Tags returns the names of the tags defined in a group (used for autocomplete)
+func (c Group) Tags(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		return c.RenderJSON([]string{})
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		return c.RenderJSON([]string{})
+	}
+
+	exists, _ := checkIfGroupIDExists(groups, id)
+	if !exists {
+		return c.RenderJSON([]string{})
+	}
+
+	tags, err := models.GetTagsForGroup(c.Log, id)
+	if err != nil {
+		return c.RenderJSON([]string{})
+	}
+
+	tagNames := make([]string, len(tags))
+	for index, tag := range tags {
+		tagNames[index] = tag.TagName
+	}
+
+	return c.RenderJSON(tagNames)
+}
+
+// TagItems displays the items of a group tagged with the given tag
+func (c Group) TagItems(id int64, tag string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to get the items of the group")
+		return c.Redirect(Home.Index)
+	}
+
+	exists, groupName := checkIfGroupIDExists(groups, id)
+	if !exists {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+		return c.Redirect(Home.Index)
+	}
+
+	tag = strings.ToLower(tag)
+	items, err := models.GetItemsByTag(c.Log, id, tag)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	tagItems := &models.TagItems{
+		GroupID:   id,
+		GroupName: groupName,
+		TagName:   tag,
+		Items:     items,
+	}
+
+	return c.Render(tagItems)
+}
+
+// Analytics displays the most viewed and the least viewed items of the group to the group leaders
+func (c Group) Analytics(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to get the analytics of the group")
+		return c.Redirect(Home.Index)
+	}
+
+	exists, groupName := checkIfGroupIDExists(groups, id)
+	if !exists {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+		return c.Redirect(Home.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intUserID, id, common.PermissionManageSettings)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", id)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. Only the group leaders can view the analytics of the group.")
+		return c.Redirect("/groups/%d", id)
+	}
+
+	analytics, err := models.GetGroupAnalytics(c.Log, id)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", id)
+	}
+	analytics.GroupName = groupName
+
+	return c.Render(analytics)
+}
+
+// Moderation is the GET action for the queue of the items pending for approval, for the leaders of the group
+func (c Group) Moderation(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	canApprove, err := hasGroupPermission(c.Log, intUserID, id, common.PermissionApproveItems)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", id)
+	}
+	if !canApprove {
+		c.Flash.Error("Unauthorized. Only the group leaders can moderate the uploads of the group.")
+		return c.Redirect("/groups/%d", id)
+	}
+
+	group, err := models.GetGroupDetailUsingID(id)
+	if err != nil {
+		c.Log.Errorf("Unable to get the group - %d. Error: %s", id, err.Error())
+		c.Flash.Error("Group details not available")
+		return c.Redirect(Group.Index)
+	}
+
+	items, err := models.GetPendingItems(c.Log, id)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	queue := &models.ModerationQueue{
+		GroupID:   id,
+		GroupName: group.GroupName,
+		Items:     items,
+	}
+
+	return c.Render(queue)
+}
+
+// getGroupGallery returns a page of the group's items if the user has access to the group
+func getGroupGallery(log logger.MultiLogger, userID, groupID int64, itemTypeID int, uploaderID int64, sort, cursor string) (*models.GroupGallery, error) {
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(userID)
+	if err != nil {
+		log.Errorf("Unable to get the groups for user - %d. Error: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the items of the group")
+	}
+
+	feedQuery, err := models.NewFeedQuery(groups, groupID, itemTypeID, sort, cursor)
+	if err != nil {
+		return nil, err
+	}
+	feedQuery.UploaderID = uploaderID
+
+	uploaders, err := models.GetUsersInGroups(log, []int64{groupID})
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := models.GetItemsByGroupIDs(log, feedQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	gallery := &models.GroupGallery{
+		Query:     feedQuery,
+		Uploaders: uploaders,
+		Page:      page,
+	}
+
+	return gallery, nil
+}
+
+// removeItemFiles removes the files of the items already deleted from the database
+// The items are gone at this point, so failures are only logged
+func removeItemFiles(log logger.MultiLogger, items []*models.Item) {
+	for _, item := range items {
+		err := os.Remove(fmt.Sprintf("./%s", item.ItemPath))
+		if err != nil {
+			log.Errorf("Unable to remove the file of the deleted item - %d. Error: %s", item.ItemID, err.Error())
+		}
+	}
+}
+
+// validateGroupName validates the name of a new or a renamed group
+func (c Group) validateGroupName(group string) {
+	c.Validation.Required(group).Message("Group name is required")
+	c.Validation.MaxSize(group, 60).Message("Group name should be less than 60 characters")
+	c.Validation.Match(group, regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_.' ]+$")).Message("Group name should start with an alphabet and must include only alphabets (a-z, A-Z), numbers (0-9) and symbols (. and _)")
+}
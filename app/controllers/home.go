@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/revel/revel"
@@ -13,7 +14,9 @@ type Home struct {
 }
 
 // Index is the GET action for Home/Index page
-func (c Home) Index() revel.Result {
+// The first page of the feed is rendered, subsequent pages are fetched using the Feed action
+// unseen limits the feed to the items the user has not seen yet
+func (c Home) Index(group int64, itemType int, sort string, unseen bool) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
@@ -25,7 +28,7 @@ func (c Home) Index() revel.Result {
 		return c.Redirect(Account.Index)
 	}
 
-	homeItems, err := models.GetHomePageData(c.Log, intUserID)
+	homeItems, err := models.GetHomePageData(c.Log, intUserID, group, itemType, sort, unseen)
 	if err != nil {
 		c.Flash.Error(err.Error())
 	}
@@ -33,6 +36,88 @@ func (c Home) Index() revel.Result {
 	return c.Render(homeItems)
 }
 
+// Feed is the GET action returning a page of the item feed as JSON
+// cursor is the NextCursor of the previous page, empty for the first page
+func (c Home) Feed(group int64, itemType int, sort, cursor string, unseen bool) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Response.Status = http.StatusUnauthorized
+		return c.RenderJSON(map[string]string{"error": "Please login to continue"})
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Response.Status = http.StatusInternalServerError
+		return c.RenderJSON(map[string]string{"error": "Unable to fetch the item metadata"})
+	}
+
+	feedQuery, err := models.NewFeedQuery(groups, group, itemType, sort, cursor)
+	if err != nil {
+		c.Response.Status = http.StatusBadRequest
+		return c.RenderJSON(map[string]string{"error": err.Error()})
+	}
+	feedQuery.UserID = intUserID
+	feedQuery.UnseenOnly = unseen
+
+	feedPage, err := models.GetItemsByGroupIDs(c.Log, feedQuery)
+	if err != nil {
+		c.Response.Status = http.StatusBadRequest
+		return c.RenderJSON(map[string]string{"error": err.Error()})
+	}
+
+	return c.RenderJSON(feedPage)
+}
+
+// MarkSeen marks the items and comments of the given group as seen, all the groups of the user if group is 0
+func (c Home) MarkSeen(group int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get all the groups for Authz check
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to mark the items as seen")
+		return c.Redirect(Home.Index)
+	}
+
+	var groupIDs []int64
+	for _, userGroup := range groups {
+		if group == 0 || userGroup.GroupID == group {
+			groupIDs = append(groupIDs, userGroup.GroupID)
+		}
+	}
+	if group > 0 && len(groupIDs) == 0 {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+		return c.Redirect(Home.Index)
+	}
+
+	err = models.MarkGroupsAsSeen(c.Log, intUserID, groupIDs)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	if group > 0 {
+		return c.Redirect("/home?group=%d", group)
+	}
+	return c.Redirect(Home.Index)
+}
+
 // checkIfGroupIDExists checks whether a groupID exists in list of groups
 func checkIfGroupIDExists(allGroups []*models.GroupKeyVal, groupID int64) (bool, string) {
 	for _, group := range allGroups {
@@ -43,3 +128,15 @@ func checkIfGroupIDExists(allGroups []*models.GroupKeyVal, groupID int64) (bool,
 
 	return false, ""
 }
+
+// checkIfGroupMember checks whether the user is a member of the group in the list of groups
+// The public groups are in the list of every approved user but only the members can add content to them
+func checkIfGroupMember(allGroups []*models.GroupKeyVal, groupID int64) (bool, string) {
+	for _, group := range allGroups {
+		if group.GroupID == groupID {
+			return group.IsMember, group.GroupName
+		}
+	}
+
+	return false, ""
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/models"
+)
+
+// Invitation is the controller for the invitations to join the groups
+type Invitation struct {
+	*revel.Controller
+}
+
+// Index is the GET action for the pending invitations of the logged in user
+func (c Invitation) Index() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	invitations, err := models.GetPendingInvitationsForUser(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Render(invitations)
+}
+
+// Create is the POST action for a leader inviting a user to the group by username or email
+func (c Invitation) Create(groupID int64, invitee string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionManageMembers)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+	if !canManage {
+		c.Flash.Error("You do not have sufficient privileges to invite users to the group")
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	invitation, err := models.InviteToGroup(c.Log, groupID, intUserID, invitee)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	if invitation.UserID == 0 {
+		c.Flash.Success("'%s' is not registered yet. Share the signup link listed under the invitations with them", invitation.Email)
+		return c.Redirect("/groups/%d", groupID)
+	}
+
+	c.Flash.Success("Successfully invited the user to the group")
+	return c.Redirect("/groups/%d", groupID)
+}
+
+// Accept is the POST action for the invitee accepting an invitation
+func (c Invitation) Accept(invitationID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	invitation, err := models.GetInvitationByID(c.Log, invitationID)
+	if err != nil || invitation.UserID != intUserID {
+		c.Flash.Error("The invitation does not exist")
+		return c.Redirect(Invitation.Index)
+	}
+
+	err = invitation.Accept(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Invitation.Index)
+	}
+
+	c.Flash.Success("You have joined the group")
+	return c.Redirect("/groups/%d", invitation.GroupID)
+}
+
+// Decline is the POST action for the invitee declining an invitation
+func (c Invitation) Decline(invitationID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	invitation, err := models.GetInvitationByID(c.Log, invitationID)
+	if err != nil || invitation.UserID != intUserID {
+		c.Flash.Error("The invitation does not exist")
+		return c.Redirect(Invitation.Index)
+	}
+
+	err = invitation.Respond(c.Log, common.InvitationDeclined)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Invitation.Index)
+	}
+
+	c.Flash.Success("Invitation declined")
+	return c.Redirect(Invitation.Index)
+}
+
+// Revoke is the POST action for a leader withdrawing a pending invitation of the group
+func (c Invitation) Revoke(invitationID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	invitation, err := models.GetInvitationByID(c.Log, invitationID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	canManage, err := hasGroupPermission(c.Log, intUserID, invitation.GroupID, common.PermissionManageMembers)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", invitation.GroupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to revoke the invitation.")
+		return c.Redirect("/groups/%d", invitation.GroupID)
+	}
+
+	err = invitation.Respond(c.Log, common.InvitationRevoked)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", invitation.GroupID)
+	}
+
+	c.Flash.Success("Invitation revoked")
+	return c.Redirect("/groups/%d", invitation.GroupID)
+}
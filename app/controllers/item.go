@@ -2,14 +2,15 @@ package controllers
 
 import (
 	"fmt"
-	"html"
 	"io/ioutil"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/revel/revel"
+	"github.com/revel/revel/logger"
 	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/models"
 )
@@ -39,12 +40,18 @@ func (c Item) Upload() revel.Result {
 		c.Flash.Error("Could not load data")
 	}
 
+	// Only the groups the role of the user allows uploading to
+	groupsKeyVal, err = filterGroupsByPermission(c.Log, intUserID, groupsKeyVal, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
 	return c.Render(groupsKeyVal)
 }
 
 // UploadHandler takes care of the file uploads
 // Supported files: Images (.jpeg, .jpg, .png) and videos (TODO)
-func (c Item) UploadHandler(uploadedFile []byte, group, description, name string) revel.Result {
+func (c Item) UploadHandler(uploadedFile []byte, group, description, name, tags string) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
@@ -83,6 +90,28 @@ func (c Item) UploadHandler(uploadedFile []byte, group, description, name string
 		return c.Redirect(Group.Details)
 	}
 
+	err = checkGroupNotArchived(c.Log, intGroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Item.Upload)
+	}
+
+	canUpload, err := hasGroupPermission(c.Log, intUserID, intGroupID, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Item.Upload)
+	}
+	if !canUpload {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow uploading items.")
+		return c.Redirect(Item.Upload)
+	}
+
+	tagNames, err := models.ParseTags(tags)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Item.Upload)
+	}
+
 	file := c.Params.Files["uploadedFile"][0]
 
 	// Verify Item-type
@@ -92,6 +121,18 @@ func (c Item) UploadHandler(uploadedFile []byte, group, description, name string
 		return c.Redirect(Item.Upload)
 	}
 
+	// The group may allow only some of the item types
+	settings, err := models.GetGroupSettings(c.Log, intGroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Item.Upload)
+	}
+	err = settings.CheckItemType(itemType)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Item.Upload)
+	}
+
 	fileNameHashed := common.SHA256(fmt.Sprintf("%s%d", file.Filename, time.Now().Unix()))
 	c.Log.Infof("filename hash: %s", fileNameHashed)
 	filePath := fmt.Sprintf("./uploads/%s", fileNameHashed)
@@ -113,6 +154,18 @@ func (c Item) UploadHandler(uploadedFile []byte, group, description, name string
 		CreatedBy:   intUserID,
 	}
 
+	// The uploads of the members wait for the approval of a leader when the group requires it
+	if settings.UploadApproval {
+		canApprove, err := hasGroupPermission(c.Log, intUserID, intGroupID, common.PermissionApproveItems)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect(Item.Upload)
+		}
+		if !canApprove {
+			itemModel.ApprovalStatus = common.ItemApprovalPending.GetStatusID()
+		}
+	}
+
 	// Check limits
 	err = itemModel.CheckLimits(c.Log)
 	if err != nil {
@@ -140,19 +193,41 @@ func (c Item) UploadHandler(uploadedFile []byte, group, description, name string
 		return c.Redirect(Item.Upload)
 	}
 
+	// Attach the tags to the item, before it is visible in the group
+	err = itemModel.SetItemTags(c.Log, tagNames, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Item.Upload)
+	}
+
 	// Upload the status of the item in the database to 'uploaded=true'
 	err = models.MarkItemAsUploaded(c.Log, itemModel.ItemID)
 	if err != nil {
 		c.Flash.Error(err.Error())
-	} else {
-		c.Flash.Success("Successfully uploaded the file")
+		return c.Redirect(Item.Upload)
+	}
+
+	// The members mentioned in the description of a pending item are notified once it is approved
+	if itemModel.IsPending() {
+		c.Flash.Success("Successfully uploaded the file. It will be visible in the group once a leader approves it")
+		return c.Redirect(Item.Upload)
+	}
+
+	// Notify the group members mentioned in the description
+	err = models.NotifyMentions(c.Log, intUserID, itemModel, itemModel.GroupID, 0,
+		common.NotificationMentionInDescription, description, "")
+	if err != nil {
+		c.Flash.Error(err.Error())
 	}
 
+	c.Flash.Success("Successfully uploaded the file")
 	return c.Redirect(Item.Upload)
 }
 
 // Preview is the GET action for item details
-func (c Item) Preview(id int) revel.Result {
+// album is the optional album being browsed, used for the previous/next navigation
+// group is the optional group through which the item is viewed, for the items shared into multiple groups
+func (c Item) Preview(id int, album, group int64) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
@@ -172,32 +247,175 @@ func (c Item) Preview(id int) revel.Result {
 		return c.Render(nil)
 	}
 
-	// Get Item with Comments
-	itemWithComments, err := models.GetItemDetailsWithItemID(c.Log, int64(id))
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, int64(id))
+	if err != nil {
+		c.Flash.Error("Item details unavailable")
+		return c.Redirect(Home.Index)
+	}
+
+	// Check if user has access to the item, through its primary group or a group it is shared into
+	accessGroup, hasAccess, err := models.GetItemAccessGroup(c.Log, groups, itemMeta, group)
 	if err != nil {
 		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+	if !hasAccess {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+		return c.Redirect(Home.Index)
 	}
 
-	// Check if user has access to the item
+	canView, err := canViewPendingItem(c.Log, intUserID, itemMeta)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+	if !canView {
+		c.Flash.Error("Item details unavailable")
+		return c.Redirect(Home.Index)
+	}
+
+	// Get Item with Comments
+	itemWithComments, err := models.GetItemDetailsWithItemID(c.Log, int64(id), accessGroup.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
 	if itemWithComments == nil ||
 		itemWithComments.ItemMeta == nil {
 		c.Flash.Error("Item details unavailable")
 		return c.Redirect(Home.Index)
 	}
 
-	exists, groupName := checkIfGroupIDExists(groups, itemWithComments.ItemMeta.GroupID)
-	if !exists {
-		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
+	itemWithComments.GroupName = accessGroup.GroupName
+
+	// Permissions of the logged in user, from the role in the group the item is viewed through
+	canComment, err := hasGroupPermission(c.Log, intUserID, accessGroup.GroupID, common.PermissionComment)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+	isModerator, err := hasGroupPermission(c.Log, intUserID, accessGroup.GroupID, common.PermissionDeleteOthersItems)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+	settings, err := models.GetGroupSettings(c.Log, accessGroup.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
 		return c.Redirect(Home.Index)
 	}
+	// No comments are added to an archived group, a group with the comments disabled or an item pending for approval
+	canComment = canComment && !accessGroup.IsArchived && settings.CommentsEnabled && !itemMeta.IsPending()
+	models.SetPermissions(itemWithComments.Comments, intUserID, canComment, isModerator)
+	itemWithComments.CanComment = canComment
+
+	// The leaders of the item's group approve the items pending for approval
+	if itemMeta.IsPending() {
+		itemWithComments.CanApprove, err = hasGroupPermission(c.Log, intUserID, itemMeta.GroupID, common.PermissionApproveItems)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+	}
+
+	// Record the view of the item
+	err = models.RecordItemView(c.Log, itemWithComments.ItemMeta.ItemID, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	// Views of the item, for the uploader and the moderators of the group
+	if isModerator || itemWithComments.ItemMeta.CreatedBy == intUserID {
+		viewStats, err := models.GetItemViewStats(c.Log, itemWithComments.ItemMeta.ItemID)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		itemWithComments.Views = viewStats
+	}
+
+	// Reactions on the item and the comments
+	err = models.SetReactions(c.Log, itemWithComments, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	// Whether the item is starred by the logged in user
+	isFavorite, err := models.IsFavorite(c.Log, intUserID, itemWithComments.ItemMeta.ItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+	itemWithComments.IsFavorite = isFavorite
 
-	itemWithComments.GroupName = groupName
+	// Albums of the group, for adding the item to an album (albums hold the items of their own group only)
+	if accessGroup.GroupID == itemWithComments.ItemMeta.GroupID {
+		groupAlbums, err := models.GetAlbumsForGroup(c.Log, itemWithComments.ItemMeta.GroupID)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		itemWithComments.GroupAlbums = groupAlbums
+	}
+
+	// Groups of the user the item can be shared into, for the owner of the item
+	// The moderators of the group the item is shared into can remove it from the group
+	itemWithComments.IsOwner = itemWithComments.ItemMeta.CreatedBy == intUserID
+	if itemWithComments.IsOwner && !itemMeta.IsPending() {
+		uploadGroups, err := filterGroupsByPermission(c.Log, intUserID, groups, common.PermissionUpload)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		itemWithComments.ShareableGroups = models.GetShareableGroups(uploadGroups, itemWithComments)
+	}
+
+	// Editing the item needs the upload permission in its group, deleting depends on the ownership
+	// The items of an archived group can neither be edited nor deleted
+	archived, err := models.IsGroupArchived(c.Log, itemWithComments.ItemMeta.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+	if !archived {
+		if itemWithComments.IsOwner {
+			itemWithComments.CanEdit, err = hasGroupPermission(c.Log, intUserID, itemWithComments.ItemMeta.GroupID, common.PermissionUpload)
+			if err != nil {
+				c.Flash.Error(err.Error())
+			}
+		}
+		itemWithComments.CanDelete, err = canDeleteItem(c.Log, intUserID, itemWithComments.ItemMeta.CreatedBy, itemWithComments.ItemMeta.GroupID)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+	}
+	itemWithComments.CanRemoveShare = isModerator && accessGroup.GroupID != itemWithComments.ItemMeta.GroupID
+
+	// Public share links of the item, for the owner and the leaders of the item's group
+	canManageLinks, err := canManageShareLinks(c.Log, intUserID, itemWithComments.ItemMeta.CreatedBy, itemWithComments.ItemMeta.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+	if canManageLinks {
+		shareLinks, err := models.GetShareLinks(c.Log, itemWithComments.ItemMeta.ItemID, 0)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		itemWithComments.ShareLinks = shareLinks
+	}
+
+	// Navigation within the current album (defaults to the first album containing the item)
+	var currentAlbum *models.Album
+	for _, itemAlbum := range itemWithComments.Albums {
+		if currentAlbum == nil || itemAlbum.AlbumID == album {
+			currentAlbum = itemAlbum
+		}
+	}
+	if currentAlbum != nil {
+		albumNav, err := currentAlbum.GetAlbumNavigation(c.Log, int64(id))
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		itemWithComments.AlbumNav = albumNav
+	}
 
 	return c.Render(itemWithComments)
 }
 
-// AddComment adds a comment to the given item
-func (c Item) AddComment(itemID string, comment string) revel.Result {
+// Edit is the GET action for editing the details of an item
+func (c Item) Edit(id int) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
@@ -209,32 +427,66 @@ func (c Item) AddComment(itemID string, comment string) revel.Result {
 		return c.Redirect(Account.Index)
 	}
 
-	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	user, err := models.GetUserByUserID(intUserID)
 	if err != nil {
-		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
-		c.Flash.Error("Unable to add the comment")
+		c.Log.Errorf("Unable to get user details. Error: %s", err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, int64(id))
+	if err != nil {
+		c.Flash.Error(err.Error())
 		return c.Redirect(Home.Index)
 	}
 
-	// Escape invalid characters
-	escapedComment := html.EscapeString(comment)
+	err = checkGroupNotArchived(c.Log, itemMeta.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemMeta.ItemID)
+	}
+
+	// The owner can edit the item as long as the role in the group allows uploading
+	canEdit, err := hasGroupPermission(c.Log, intUserID, itemMeta.GroupID, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemMeta.ItemID)
+	}
+	if !canEdit || (itemMeta.CreatedBy != intUserID && !user.IsAdmin) {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to edit the item.")
+		return c.Redirect("/item/%d", itemMeta.ItemID)
+	}
 
-	commentObj := &models.Comment{
-		Comment:   escapedComment,
-		ItemID:    intItemID,
-		CreatedBy: intUserID,
+	group, err := models.GetGroupDetailUsingID(itemMeta.GroupID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the group of the item - %d. Error: %s", itemMeta.ItemID, err.Error())
+		c.Flash.Error("Unable to get the details of the item")
+		return c.Redirect(Home.Index)
 	}
 
-	err = commentObj.Add(c.Log)
+	itemTags, err := models.GetTagsForItem(c.Log, itemMeta.ItemID)
 	if err != nil {
 		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemMeta.ItemID)
 	}
 
-	return c.Redirect("/item/%d", intItemID)
+	tagNames := make([]string, len(itemTags))
+	for index, tag := range itemTags {
+		tagNames[index] = tag.TagName
+	}
+
+	itemEdit := &models.ItemEdit{
+		ItemMeta:  itemMeta,
+		GroupName: group.GroupName,
+		Tags:      strings.Join(tagNames, ", "),
+	}
+
+	return c.Render(itemEdit)
 }
 
-// Delete adds a comment to the given item
-func (c Item) Delete(itemID string) revel.Result {
+// Update is the POST action for updating the details of an item
+func (c Item) Update(itemID, name, description, tags string) revel.Result {
 	userID := c.Flash.Out["userID"]
 	loggedInUser := c.Flash.Out["loggedInUser"]
 	c.Flash.Out["loggedInUser"] = loggedInUser
@@ -249,10 +501,29 @@ func (c Item) Delete(itemID string) revel.Result {
 	intItemID, err := strconv.ParseInt(itemID, 10, 64)
 	if err != nil {
 		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
-		c.Flash.Error("Unable to add the comment")
+		c.Flash.Error("Unable to update the item")
 		return c.Redirect(Home.Index)
 	}
 
+	c.Validation.Required(name).Message("Item name is required")
+	c.Validation.Required(description).Message("Description is required")
+	c.Validation.MaxSize(name, 30).Message("Item name should be less than 30 characters")
+	c.Validation.MaxSize(description, 400).Message("Description should be less than 400 characters")
+	c.Validation.Match(name, regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_]+$")).Message("Item name should start with an alphabet and must include only alphabets (a-z, A-Z), numbers (0-9) and symbols (_)")
+
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect("/item/%d/edit", intItemID)
+	}
+
+	tagNames, err := models.ParseTags(tags)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d/edit", intItemID)
+	}
+
 	user, err := models.GetUserByUserID(intUserID)
 	if err != nil {
 		c.Log.Errorf("Unable to get user details. Error: %s", err.Error())
@@ -267,31 +538,935 @@ func (c Item) Delete(itemID string) revel.Result {
 		return c.Redirect(Home.Index)
 	}
 
-	if itemMeta.CreatedBy != intUserID && !user.IsAdmin {
-		c.Flash.Error("Unauthorized. You do not have enough permissions to delete the item.")
+	err = checkGroupNotArchived(c.Log, itemMeta.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemMeta.ItemID)
+	}
+
+	// The owner can edit the item as long as the role in the group allows uploading
+	canEdit, err := hasGroupPermission(c.Log, intUserID, itemMeta.GroupID, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+	if !canEdit || (itemMeta.CreatedBy != intUserID && !user.IsAdmin) {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to edit the item.")
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	previousDescription := itemMeta.Description
+	itemMeta.ItemName = name
+	itemMeta.Description = description
+	err = itemMeta.UpdateDetails(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d/edit", intItemID)
+	}
+
+	err = itemMeta.SetItemTags(c.Log, tagNames, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d/edit", intItemID)
+	}
+
+	// Notify the group members newly mentioned in the description
+	err = models.NotifyMentions(c.Log, intUserID, itemMeta, itemMeta.GroupID, 0,
+		common.NotificationMentionInDescription, description, previousDescription)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	c.Flash.Success("Successfully updated the item")
+	return c.Redirect("/item/%d", intItemID)
+}
+
+// Download is the GET action for downloading the media of an item
+func (c Item) Download(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, id)
+	if err != nil || !itemMeta.Uploaded {
+		c.Flash.Error("Item details unavailable")
 		return c.Redirect(Home.Index)
 	}
 
-	err = models.MarkItemAsDeleting(c.Log, itemMeta.ItemID)
+	// Check if user has access to the item
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
 	if err != nil {
-		c.Flash.Error("Unable to delete the item at the moment")
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to download the item")
+		return c.Redirect("/item/%d", id)
+	}
+	_, hasAccess, err := models.GetItemAccessGroup(c.Log, groups, itemMeta, 0)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", id)
+	}
+	if !hasAccess {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to view the content")
 		return c.Redirect(Home.Index)
 	}
 
-	// Delete file
-	err = os.Remove(fmt.Sprintf("./%s", itemMeta.ItemPath))
+	canView, err := canViewPendingItem(c.Log, intUserID, itemMeta)
 	if err != nil {
-		c.Flash.Error("Unable to delete the item at the moment")
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+	if !canView {
+		c.Flash.Error("Item details unavailable")
 		return c.Redirect(Home.Index)
 	}
 
-	// Delete the item
-	err = itemMeta.Delete(c.Log)
+	// Record the view of the item
+	err = models.RecordItemView(c.Log, id, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.RenderFileName("."+itemMeta.ItemPath, revel.Attachment)
+}
+
+// AddComment adds a comment to the given item
+// parentCommentID is set when the comment is a reply to another comment
+// groupID is the group through which the item is viewed, the comment is posted in the group
+func (c Item) AddComment(itemID, comment, parentCommentID string, groupID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to add the comment")
+		return c.Redirect(Home.Index)
+	}
+
+	var intParentCommentID int64
+	if parentCommentID != "" {
+		intParentCommentID, err = strconv.ParseInt(parentCommentID, 10, 64)
+		if err != nil {
+			c.Log.Errorf("Unable to parse the parent comment ID - %s. Error: %s", parentCommentID, err.Error())
+			c.Flash.Error("Unable to add the comment")
+			return c.Redirect("/item/%d", intItemID)
+		}
+	}
+
+	if strings.TrimSpace(comment) == "" {
+		c.Flash.Error("Comment is required")
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, intItemID)
 	if err != nil {
 		c.Flash.Error(err.Error())
 		return c.Redirect(Home.Index)
 	}
 
-	c.Flash.Success("Successfully deleted the item")
-	return c.Redirect(Home.Index)
+	// Check if user has access to the item
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to add the comment")
+		return c.Redirect("/item/%d", intItemID)
+	}
+	accessGroup, hasAccess, err := models.GetItemAccessGroup(c.Log, groups, itemMeta, groupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+	if !hasAccess {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to comment on the item")
+		return c.Redirect(Home.Index)
+	}
+
+	if accessGroup.IsArchived {
+		c.Flash.Error("The group is archived. Its content is read-only")
+		return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+	}
+	if itemMeta.IsPending() {
+		c.Flash.Error("Comments can be added once the item is approved")
+		return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+	}
+
+	err = checkCommentsEnabled(c.Log, accessGroup.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+	}
+
+	canComment, err := hasGroupPermission(c.Log, intUserID, accessGroup.GroupID, common.PermissionComment)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+	}
+	if !canComment {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow commenting.")
+		return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+	}
+
+	// When the comments are kept per group, replies are allowed only to the comments of the same group
+	if intParentCommentID > 0 && itemMeta.CommentScope == common.CommentScopeGroup {
+		parent, err := models.GetCommentByID(c.Log, intParentCommentID)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+		}
+		parentGroupID := parent.GroupID
+		if parentGroupID == 0 {
+			parentGroupID = itemMeta.GroupID
+		}
+		if parentGroupID != accessGroup.GroupID {
+			c.Flash.Error("The comment being replied to does not exist")
+			return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+		}
+	}
+
+	// The comment is stored as raw text and sanitized when it is rendered
+	commentObj := &models.Comment{
+		Comment:         comment,
+		ItemID:          intItemID,
+		ParentCommentID: intParentCommentID,
+		CreatedBy:       intUserID,
+		GroupID:         accessGroup.GroupID,
+	}
+
+	err = commentObj.Add(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d?group=%d", intItemID, accessGroup.GroupID)
+	}
+
+	// Notify the group members mentioned in the comment
+	err = models.NotifyMentions(c.Log, intUserID, itemMeta, accessGroup.GroupID, commentObj.CommentID,
+		common.NotificationMentionInComment, comment, "")
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect("/item/%d?group=%d#comment-%d", intItemID, accessGroup.GroupID, commentObj.CommentID)
+}
+
+// EditComment updates the text of a comment, only the author can edit the comment
+func (c Item) EditComment(commentID, comment string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intCommentID, err := strconv.ParseInt(commentID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the comment ID - %s. Error: %s", commentID, err.Error())
+		c.Flash.Error("Unable to update the comment")
+		return c.Redirect(Home.Index)
+	}
+
+	commentObj, err := models.GetCommentByID(c.Log, intCommentID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	if commentObj.CreatedBy != intUserID {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to edit the comment.")
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	if strings.TrimSpace(comment) == "" {
+		c.Flash.Error("Comment is required")
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	itemMeta, err := models.GetItemDetailsByID(c.Log, commentObj.ItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	// Comments without a group were posted in the primary group of the item
+	commentGroupID := commentObj.GroupID
+	if commentGroupID == 0 {
+		commentGroupID = itemMeta.GroupID
+	}
+	err = checkGroupNotArchived(c.Log, commentGroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+	err = checkCommentsEnabled(c.Log, commentGroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	canComment, err := hasGroupPermission(c.Log, intUserID, commentGroupID, common.PermissionComment)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+	if !canComment {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow commenting.")
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	// The comment is stored as raw text and sanitized when it is rendered
+	previousComment := commentObj.Comment
+	commentObj.Comment = comment
+
+	err = commentObj.Update(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	// Notify the group members newly mentioned in the comment
+	err = models.NotifyMentions(c.Log, intUserID, itemMeta, commentGroupID, commentObj.CommentID,
+		common.NotificationMentionInComment, comment, previousComment)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect("/item/%d#comment-%d", commentObj.ItemID, commentObj.CommentID)
+}
+
+// DeleteComment deletes a comment
+// The author, the moderators of the group the comment was posted in and the admins can delete the comment
+func (c Item) DeleteComment(commentID string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intCommentID, err := strconv.ParseInt(commentID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the comment ID - %s. Error: %s", commentID, err.Error())
+		c.Flash.Error("Unable to delete the comment")
+		return c.Redirect(Home.Index)
+	}
+
+	commentObj, err := models.GetCommentByID(c.Log, intCommentID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	itemMeta, err := models.GetItemDetailsByID(c.Log, commentObj.ItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	// Comments without a group were posted in the primary group of the item
+	commentGroupID := commentObj.GroupID
+	if commentGroupID == 0 {
+		commentGroupID = itemMeta.GroupID
+	}
+
+	err = checkGroupNotArchived(c.Log, commentGroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	if commentObj.CreatedBy != intUserID {
+		isModerator, err := hasGroupPermission(c.Log, intUserID, commentGroupID, common.PermissionDeleteOthersItems)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect("/item/%d", commentObj.ItemID)
+		}
+		if !isModerator {
+			c.Flash.Error("Unauthorized. You do not have enough permissions to delete the comment.")
+			return c.Redirect("/item/%d", commentObj.ItemID)
+		}
+	}
+
+	err = commentObj.Delete(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", commentObj.ItemID)
+	}
+
+	c.Flash.Success("Comment deleted")
+	return c.Redirect("/item/%d", commentObj.ItemID)
+}
+
+// React toggles the reaction of the user on the given item
+// commentID is set when the reaction is on a comment of the item
+func (c Item) React(itemID, commentID, emoji string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to update the reaction")
+		return c.Redirect(Home.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, intItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	// Check if user has access to the item
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to update the reaction")
+		return c.Redirect("/item/%d", intItemID)
+	}
+	accessGroup, hasAccess, err := models.GetItemAccessGroup(c.Log, groups, itemMeta, 0)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+	// The visitors of a public group can only view its items
+	if !hasAccess || !accessGroup.IsMember {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to react to the item")
+		return c.Redirect(Home.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, accessGroup.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	reaction := &models.Reaction{
+		ItemID: intItemID,
+		UserID: intUserID,
+		Emoji:  emoji,
+	}
+
+	redirectURL := fmt.Sprintf("/item/%d", intItemID)
+	if commentID != "" {
+		reaction.CommentID, err = strconv.ParseInt(commentID, 10, 64)
+		if err != nil {
+			c.Log.Errorf("Unable to parse the comment ID - %s. Error: %s", commentID, err.Error())
+			c.Flash.Error("Unable to update the reaction")
+			return c.Redirect(redirectURL)
+		}
+
+		// The comment must belong to the item
+		commentObj, err := models.GetCommentByID(c.Log, reaction.CommentID)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect(redirectURL)
+		}
+		if commentObj.ItemID != intItemID || commentObj.IsDeleted {
+			c.Flash.Error("The comment does not exist")
+			return c.Redirect(redirectURL)
+		}
+		redirectURL = fmt.Sprintf("%s#comment-%d", redirectURL, reaction.CommentID)
+	}
+
+	err = reaction.ToggleReaction(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect(redirectURL)
+}
+
+// Share shares the item into another group of the owner, the stored file is reused
+func (c Item) Share(itemID, groupID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, itemID)
+	if err != nil || !itemMeta.Uploaded {
+		c.Flash.Error("Item details unavailable")
+		return c.Redirect(Home.Index)
+	}
+
+	if itemMeta.CreatedBy != intUserID {
+		c.Flash.Error("Unauthorized. Only the owner of the item can share it.")
+		return c.Redirect("/item/%d", itemID)
+	}
+	if itemMeta.IsPending() {
+		c.Flash.Error("The item can be shared once it is approved")
+		return c.Redirect("/item/%d", itemID)
+	}
+	if itemMeta.GroupID == groupID {
+		c.Flash.Error("The item already belongs to the group")
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	// The item can be shared only into the groups of the owner
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to get the groups for user - %d. Error: %s", intUserID, err.Error())
+		c.Flash.Error("Unable to share the item")
+		return c.Redirect("/item/%d", itemID)
+	}
+	exists, groupName := checkIfGroupIDExists(groups, groupID)
+	if !exists {
+		c.Flash.Error("Unauthorized! You do not have enough permissions to share the item with the group")
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	// Sharing into a group is an upload to the group
+	err = checkGroupNotArchived(c.Log, groupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	canUpload, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionUpload)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+	if !canUpload {
+		c.Flash.Error("Unauthorized. Your role in the group does not allow uploading items.")
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	// The settings of the group apply to the shared items as to the uploads
+	settings, err := models.GetGroupSettings(c.Log, groupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+	err = settings.CheckItemType(common.ItemType(itemMeta.ItemTypeID))
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+	if settings.UploadApproval {
+		canApprove, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionApproveItems)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect("/item/%d", itemID)
+		}
+		if !canApprove {
+			c.Flash.Error("The uploads to the group '%s' need the approval of a leader. Upload the item to the group instead.", groupName)
+			return c.Redirect("/item/%d", itemID)
+		}
+	}
+
+	share := &models.ItemShare{
+		ItemID:   itemID,
+		GroupID:  groupID,
+		SharedBy: intUserID,
+	}
+	err = share.Add(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	c.Flash.Success("Item shared with the group '%s'", groupName)
+	return c.Redirect("/item/%d", itemID)
+}
+
+// Unshare removes the item from a group it was shared into, the item itself is not deleted
+// The owner of the item and the moderators of the group can remove the item from the group
+func (c Item) Unshare(itemID, groupID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, itemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	if itemMeta.CreatedBy != intUserID {
+		isModerator, err := hasGroupPermission(c.Log, intUserID, groupID, common.PermissionDeleteOthersItems)
+		if err != nil {
+			c.Flash.Error(err.Error())
+			return c.Redirect("/item/%d", itemID)
+		}
+		if !isModerator {
+			c.Flash.Error("Unauthorized. You do not have enough permissions to remove the item from the group.")
+			return c.Redirect("/item/%d", itemID)
+		}
+	}
+
+	share := &models.ItemShare{
+		ItemID:  itemID,
+		GroupID: groupID,
+	}
+	err = share.Remove(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	c.Flash.Success("Removed the item from the group")
+	if itemMeta.CreatedBy != intUserID {
+		return c.Redirect("/groups/%d", groupID)
+	}
+	return c.Redirect("/item/%d", itemID)
+}
+
+// UpdateCommentScope sets whether the comments of the item are shared by all its groups or kept per group
+// Only the owner of the item can change the setting
+func (c Item) UpdateCommentScope(itemID int64, scope string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, itemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	if itemMeta.CreatedBy != intUserID {
+		c.Flash.Error("Unauthorized. Only the owner of the item can change the comment setting.")
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	itemMeta.CommentScope = scope
+	err = itemMeta.UpdateCommentScope(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	c.Flash.Success("Updated the comment setting of the item")
+	return c.Redirect("/item/%d", itemID)
+}
+
+// Approve publishes an item pending for approval, only the leaders of the item's group can approve it
+// reason is the optional note sent to the uploader, queue is set when the item is approved from the moderation queue
+func (c Item) Approve(itemID int64, reason string, queue bool) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	c.Validation.MaxSize(reason, 400).Message("Reason should be less than 400 characters")
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, itemID)
+	if err != nil || !itemMeta.Uploaded {
+		c.Flash.Error("Item details unavailable")
+		return c.Redirect(Home.Index)
+	}
+
+	canApprove, err := hasGroupPermission(c.Log, intUserID, itemMeta.GroupID, common.PermissionApproveItems)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+	if !canApprove {
+		c.Flash.Error("Unauthorized. Only the group leaders can approve the items.")
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	err = itemMeta.Approve(c.Log, intUserID, strings.TrimSpace(reason))
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", itemID)
+	}
+
+	// The members mentioned in the description are notified now that they can view the item
+	err = models.NotifyMentions(c.Log, itemMeta.CreatedBy, itemMeta, itemMeta.GroupID, 0,
+		common.NotificationMentionInDescription, itemMeta.Description, "")
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	c.Flash.Success("The item '%s' is approved and visible in the group", itemMeta.ItemName)
+	if queue {
+		return c.Redirect("/groups/%d/moderation", itemMeta.GroupID)
+	}
+	return c.Redirect("/item/%d", itemID)
+}
+
+// Reject deletes an item pending for approval along with its file, only the leaders of the item's group can reject it
+// reason is the optional note sent to the uploader
+func (c Item) Reject(itemID int64, reason string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, itemID)
+	if err != nil {
+		c.Flash.Error("Item details unavailable")
+		return c.Redirect(Home.Index)
+	}
+
+	c.Validation.MaxSize(reason, 400).Message("Reason should be less than 400 characters")
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect("/groups/%d/moderation", itemMeta.GroupID)
+	}
+
+	canApprove, err := hasGroupPermission(c.Log, intUserID, itemMeta.GroupID, common.PermissionApproveItems)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d/moderation", itemMeta.GroupID)
+	}
+	if !canApprove {
+		c.Flash.Error("Unauthorized. Only the group leaders can reject the items.")
+		return c.Redirect("/groups/%d", itemMeta.GroupID)
+	}
+
+	err = itemMeta.Reject(c.Log, intUserID, strings.TrimSpace(reason))
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d/moderation", itemMeta.GroupID)
+	}
+
+	// The item no longer counts towards the limits, a file left behind is only logged
+	removeItemFiles(c.Log, []*models.Item{itemMeta})
+
+	c.Flash.Success("The item '%s' is rejected and deleted", itemMeta.ItemName)
+	return c.Redirect("/groups/%d/moderation", itemMeta.GroupID)
+}
+
+// Delete adds a comment to the given item
+func (c Item) Delete(itemID string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	intItemID, err := strconv.ParseInt(itemID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Unable to parse the item ID - %s. Error: %s", itemID, err.Error())
+		c.Flash.Error("Unable to add the comment")
+		return c.Redirect(Home.Index)
+	}
+
+	// Get Item details
+	itemMeta, err := models.GetItemDetailsByID(c.Log, intItemID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	err = checkGroupNotArchived(c.Log, itemMeta.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/item/%d", intItemID)
+	}
+
+	canDelete, err := canDeleteItem(c.Log, intUserID, itemMeta.CreatedBy, itemMeta.GroupID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+	if !canDelete {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to delete the item.")
+		return c.Redirect(Home.Index)
+	}
+
+	err = models.MarkItemAsDeleting(c.Log, itemMeta.ItemID)
+	if err != nil {
+		c.Flash.Error("Unable to delete the item at the moment")
+		return c.Redirect(Home.Index)
+	}
+
+	// Delete file
+	err = os.Remove(fmt.Sprintf("./%s", itemMeta.ItemPath))
+	if err != nil {
+		c.Flash.Error("Unable to delete the item at the moment")
+		return c.Redirect(Home.Index)
+	}
+
+	// Delete the item
+	err = itemMeta.Delete(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	c.Flash.Success("Successfully deleted the item")
+	return c.Redirect(Home.Index)
+}
+
+// hasGroupPermission checks whether the role of the user in the group grants the permission
+// Admins have all the permissions in every group
+func hasGroupPermission(log logger.MultiLogger, userID, groupID int64, permission common.GroupPermission) (bool, error) {
+	user, err := models.GetUserByUserID(userID)
+	if err != nil {
+		log.Errorf("Unable to get user details. Error: %s", err.Error())
+		return false, fmt.Errorf("Unable to process the request")
+	}
+	if user.IsAdmin {
+		return true, nil
+	}
+
+	role, err := models.GetGroupRole(log, userID, groupID)
+	if err != nil {
+		// error is already logged
+		return false, err
+	}
+
+	return role.Can(permission), nil
+}
+
+// canDeleteItem checks whether the user can delete the item created by ownerID in the group
+func canDeleteItem(log logger.MultiLogger, userID, ownerID, groupID int64) (bool, error) {
+	if ownerID == userID {
+		return hasGroupPermission(log, userID, groupID, common.PermissionDeleteOwnItems)
+	}
+	return hasGroupPermission(log, userID, groupID, common.PermissionDeleteOthersItems)
+}
+
+// filterGroupsByPermission returns the groups in which the role of the user grants the permission
+// The archived groups are left out for the permissions changing the content
+func filterGroupsByPermission(log logger.MultiLogger, userID int64, groups []*models.GroupKeyVal, permission common.GroupPermission) ([]*models.GroupKeyVal, error) {
+	user, err := models.GetUserByUserID(userID)
+	if err != nil {
+		log.Errorf("Unable to get user details. Error: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var roles map[int64]common.GroupRole
+	if !user.IsAdmin {
+		roles, err = models.GetGroupRoles(log, userID)
+		if err != nil {
+			// error is already logged
+			return nil, err
+		}
+	}
+
+	var allowed []*models.GroupKeyVal
+	for _, group := range groups {
+		if group.IsArchived && permission.IsWrite() {
+			continue
+		}
+		if user.IsAdmin || roles[group.GroupID].Can(permission) {
+			allowed = append(allowed, group)
+		}
+	}
+
+	return allowed, nil
+}
+
+// canViewPendingItem checks whether the user can view the item if it is pending for approval
+// The pending items are visible only to the uploader and the leaders of the item's group
+func canViewPendingItem(log logger.MultiLogger, userID int64, item *models.Item) (bool, error) {
+	if !item.IsPending() || item.CreatedBy == userID {
+		return true, nil
+	}
+	return hasGroupPermission(log, userID, item.GroupID, common.PermissionApproveItems)
+}
+
+// checkCommentsEnabled returns an error if the comments are disabled in the settings of the group
+func checkCommentsEnabled(log logger.MultiLogger, groupID int64) error {
+	settings, err := models.GetGroupSettings(log, groupID)
+	if err != nil {
+		// error is already logged
+		return err
+	}
+	if !settings.CommentsEnabled {
+		return fmt.Errorf("Comments are disabled in the group")
+	}
+
+	return nil
+}
+
+// checkGroupNotArchived returns an error if the group is archived, the archived groups are read-only
+func checkGroupNotArchived(log logger.MultiLogger, groupID int64) error {
+	archived, err := models.IsGroupArchived(log, groupID)
+	if err != nil {
+		// error is already logged
+		return err
+	}
+	if archived {
+		return fmt.Errorf("The group is archived. Its content is read-only")
+	}
+
+	return nil
 }
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/models"
+)
+
+// JoinRequest is the controller for the requests of the users to join the groups
+type JoinRequest struct {
+	*revel.Controller
+}
+
+// Index is the GET action for browsing the groups to join along with the requests of the logged in user
+func (c JoinRequest) Index() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	joinRequests := &models.JoinRequestList{}
+	joinRequests.Groups, err = models.GetJoinableGroups(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	joinRequests.Requests, err = models.GetJoinRequestsForUser(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Render(joinRequests)
+}
+
+// Create is the POST action for requesting to join a group, message is shown to the leaders of the group
+func (c JoinRequest) Create(groupID int64, message string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	c.Validation.MaxSize(message, 500).Message("Message should be 500 characters or less")
+	if c.Validation.HasErrors() {
+		// Store the validation errors in the flash context and redirect.
+		c.Validation.Keep()
+		c.FlashParams()
+		return c.Redirect(JoinRequest.Index)
+	}
+
+	joinRequest := &models.JoinRequest{
+		GroupID: groupID,
+		UserID:  intUserID,
+		Message: message,
+	}
+	err = joinRequest.Add(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(JoinRequest.Index)
+	}
+
+	c.Flash.Success("Your request has been sent to the leaders of the group")
+	return c.Redirect(JoinRequest.Index)
+}
+
+// Handle is the POST action for a leader of the group approving or rejecting a join request
+func (c JoinRequest) Handle(requestID int64, approve bool) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	joinRequest, err := models.GetJoinRequestByID(c.Log, requestID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Group.Index)
+	}
+
+	// Join requests are handled by the members who can manage the members of the group
+	canManage, err := hasGroupPermission(c.Log, intUserID, joinRequest.GroupID, common.PermissionManageMembers)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", joinRequest.GroupID)
+	}
+	if !canManage {
+		c.Flash.Error("Unauthorized. You do not have enough permissions to handle the join requests.")
+		return c.Redirect("/groups/%d", joinRequest.GroupID)
+	}
+
+	err = joinRequest.ApproveOrReject(c.Log, approve, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect("/groups/%d", joinRequest.GroupID)
+	}
+
+	if approve {
+		c.Flash.Success("Join request approved")
+	} else {
+		c.Flash.Success("Join request rejected")
+	}
+	return c.Redirect("/groups/%d", joinRequest.GroupID)
+}
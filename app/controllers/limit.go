@@ -357,7 +357,7 @@ func (c Limit) UpdateGroupLimits(group, maxCount, maxSpace string) revel.Result
 		MaxItemSpace: float32(intMaxSpace),
 	}
 
-	err = groupToUpdate.UpdateLimits(c.Log)
+	err = groupToUpdate.UpdateLimits(c.Log, intUserID)
 	if err != nil {
 		c.Flash.Error(err.Error())
 		return c.Redirect(Limit.Groups)
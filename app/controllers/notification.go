@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/models"
+)
+
+// Notification is the controller for the notifications of the logged in user
+type Notification struct {
+	*revel.Controller
+}
+
+// Index is the GET action for the notifications page
+func (c Notification) Index() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	notifications, err := models.GetNotificationsForUser(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Render(notifications)
+}
+
+// Open marks the notification as read and redirects to its content
+func (c Notification) Open(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	notification, err := models.MarkNotificationAsRead(c.Log, intUserID, id)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Notification.Index)
+	}
+
+	return c.Redirect(notification.GetLink())
+}
+
+// MarkAllRead marks all the notifications of the user as read
+func (c Notification) MarkAllRead() revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	err = models.MarkAllNotificationsAsRead(c.Log, intUserID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	return c.Redirect(Notification.Index)
+}
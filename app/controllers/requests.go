@@ -280,7 +280,7 @@ func (c Requests) HandleGroupAccess(groupID, memberID, approve string) revel.Res
 	}
 
 	// Push the group into database table
-	err = userGroupModel.ApproveOrReject(c.Log, approveFlag)
+	err = userGroupModel.ApproveOrReject(c.Log, approveFlag, intUserID)
 	if err != nil {
 		c.Flash.Error("Unable to process the request at the moment. Please try after sometime.")
 	}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/models"
+)
+
+// Search is the controller for full-text search over items and comments
+type Search struct {
+	*revel.Controller
+}
+
+// Index is the GET action for the search page
+func (c Search) Index(q, itemType, group, uploader, from, to string) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	filter := &models.SearchFilter{
+		Query: strings.TrimSpace(q),
+		From:  strings.TrimSpace(from),
+		To:    strings.TrimSpace(to),
+	}
+
+	// Optional filters (invalid values are ignored)
+	if itemType != "" {
+		intItemType, err := strconv.Atoi(itemType)
+		if err == nil {
+			filter.ItemTypeID = intItemType
+		}
+	}
+	if group != "" {
+		intGroupID, err := strconv.ParseInt(group, 10, 64)
+		if err == nil {
+			filter.GroupID = intGroupID
+		}
+	}
+	if uploader != "" {
+		intUploaderID, err := strconv.ParseInt(uploader, 10, 64)
+		if err == nil {
+			filter.UploaderID = intUploaderID
+		}
+	}
+
+	// Get all the groups visible to the user (used for the filters)
+	groups, err := models.GetAllGroupsKeyVal(intUserID)
+	if err != nil {
+		c.Log.Errorf("Unable to fetch list of groups. Error: %s", err.Error())
+		c.Flash.Error("Could not load data")
+	}
+
+	groupIDs := make([]int64, len(groups))
+	for index, group := range groups {
+		groupIDs[index] = group.GroupID
+	}
+
+	uploaders, err := models.GetUsersInGroups(c.Log, groupIDs)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	searchView := &models.SearchView{
+		Filter:    filter,
+		Groups:    groups,
+		Uploaders: uploaders,
+	}
+
+	if len(filter.Query) > 200 {
+		c.Flash.Error("Search query should be 200 characters or less")
+	} else if filter.Query != "" {
+		results, err := models.SearchItems(c.Log, intUserID, filter)
+		if err != nil {
+			c.Flash.Error(err.Error())
+		}
+		searchView.Results = results
+	}
+
+	return c.Render(searchView)
+}
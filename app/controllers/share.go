@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/revel/revel"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Share is the controller for the anonymous read-only access through the share links
+// The actions do not require a login, all the accesses are logged against the share link
+type Share struct {
+	*revel.Controller
+}
+
+// Open is the GET action for the item or the album of a share link
+func (c Share) Open(token string) revel.Result {
+	link, result := c.checkShareLink(token, 0)
+	if result != nil {
+		return result
+	}
+
+	sharedContent, err := models.GetSharedContent(c.Log, link)
+	if err != nil {
+		c.logAccess(link, 0, common.ShareLinkAccessDenied)
+		return c.RenderTemplate("Share/Unavailable.html")
+	}
+	c.logAccess(link, link.ItemID, common.ShareLinkAccessOpen)
+
+	return c.Render(sharedContent)
+}
+
+// Unlock is the POST action for entering the password of a share link
+func (c Share) Unlock(token, password string) revel.Result {
+	link, err := models.GetShareLinkByToken(c.Log, token)
+	if err != nil || !link.IsActive() {
+		return c.RenderTemplate("Share/Unavailable.html")
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password))
+	if err != nil {
+		c.logAccess(link, 0, common.ShareLinkAccessDenied)
+		c.Flash.Error("Invalid password")
+		return c.Redirect("/s/%s", token)
+	}
+
+	c.Session[shareLinkSessionKey(link)] = true
+	return c.Redirect("/s/%s", token)
+}
+
+// Media is the GET action for viewing the media of the shared item (or an item of the shared album)
+func (c Share) Media(token string, itemID int64) revel.Result {
+	return c.serveItem(token, itemID, false)
+}
+
+// Download is the GET action for downloading the shared item, if the share link allows downloads
+func (c Share) Download(token string, itemID int64) revel.Result {
+	return c.serveItem(token, itemID, true)
+}
+
+// serveItem serves the media of an item through the share link, inline or as a download
+func (c Share) serveItem(token string, itemID int64, download bool) revel.Result {
+	link, result := c.checkShareLink(token, itemID)
+	if result != nil {
+		return result
+	}
+
+	if download && !link.AllowDownload {
+		c.logAccess(link, itemID, common.ShareLinkAccessDenied)
+		return c.Forbidden("Downloads are not allowed through this link")
+	}
+
+	canAccess, err := link.CanAccessItem(c.Log, itemID)
+	if err != nil || !canAccess {
+		c.logAccess(link, itemID, common.ShareLinkAccessDenied)
+		return c.NotFound("Item details unavailable")
+	}
+
+	itemMeta, err := models.GetItemDetailsByID(c.Log, itemID)
+	if err != nil || !itemMeta.Uploaded || itemMeta.IsPending() {
+		return c.NotFound("Item details unavailable")
+	}
+
+	if download {
+		c.logAccess(link, itemID, common.ShareLinkAccessDownload)
+		return c.RenderFileName("."+itemMeta.ItemPath, revel.Attachment)
+	}
+
+	c.logAccess(link, itemID, common.ShareLinkAccessView)
+	return c.RenderFileName("."+itemMeta.ItemPath, revel.Inline)
+}
+
+// checkShareLink returns the share link for the token if it can be used
+// A result is returned instead when the link is unavailable or its password has not been entered yet
+func (c Share) checkShareLink(token string, itemID int64) (*models.ShareLink, revel.Result) {
+	link, err := models.GetShareLinkByToken(c.Log, token)
+	if err != nil {
+		return nil, c.RenderTemplate("Share/Unavailable.html")
+	}
+
+	if !link.IsActive() {
+		c.logAccess(link, itemID, common.ShareLinkAccessDenied)
+		return nil, c.RenderTemplate("Share/Unavailable.html")
+	}
+
+	if link.HasPassword() {
+		if _, unlocked := c.Session[shareLinkSessionKey(link)]; !unlocked {
+			c.ViewArgs["token"] = token
+			return nil, c.RenderTemplate("Share/Password.html")
+		}
+	}
+
+	return link, nil
+}
+
+// logAccess logs the access through the share link along with the client details
+func (c Share) logAccess(link *models.ShareLink, itemID int64, action string) {
+	models.LogShareLinkAccess(c.Log, &models.ShareLinkAccess{
+		LinkID:    link.LinkID,
+		ItemID:    itemID,
+		Action:    action,
+		IPAddress: c.ClientIP,
+		UserAgent: c.Request.GetHttpHeader("User-Agent"),
+	})
+}
+
+// shareLinkSessionKey is the session key set once the password of the share link is entered
+func shareLinkSessionKey(link *models.ShareLink) string {
+	return fmt.Sprintf("shareLink%d", link.LinkID)
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/revel/revel"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ShareLink is the controller for managing the public share links of the items and the albums
+type ShareLink struct {
+	*revel.Controller
+}
+
+// Create is the POST action for creating a share link for an item or an album (if itemID is 0)
+// expiresOn is the optional last day the link can be used, password is optional
+func (c ShareLink) Create(itemID, albumID int64, expiresOn, password string, allowDownload bool) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	groupID, redirectURL, err := getShareLinkTarget(c.Log, intUserID, itemID, albumID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		if redirectURL == "" {
+			return c.Redirect(Home.Index)
+		}
+		return c.Redirect(redirectURL)
+	}
+
+	link := &models.ShareLink{
+		ItemID:        itemID,
+		GroupID:       groupID,
+		AllowDownload: allowDownload,
+		CreatedBy:     intUserID,
+	}
+	if itemID == 0 {
+		link.AlbumID = albumID
+	}
+
+	if expiresOn != "" {
+		expiryDate, err := time.Parse(common.SearchDateFormat, expiresOn)
+		if err != nil {
+			c.Flash.Error("Invalid expiry date - '%s'", expiresOn)
+			return c.Redirect(redirectURL)
+		}
+		// The link can be used till the end of the expiry date
+		link.ExpiresAt = expiryDate.AddDate(0, 0, 1)
+		if link.IsExpired() {
+			c.Flash.Error("The expiry date must not be in the past")
+			return c.Redirect(redirectURL)
+		}
+	}
+
+	if password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			c.Log.Errorf("Unable to hash the password of the share link. Error: %s", err.Error())
+			c.Flash.Error("Unable to create the share link at the moment")
+			return c.Redirect(redirectURL)
+		}
+		link.PasswordHash = string(hashedPassword)
+	}
+
+	err = link.Add(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(redirectURL)
+	}
+
+	c.Flash.Success("Share link created")
+	return c.Redirect(redirectURL)
+}
+
+// Revoke is the POST action for revoking a share link
+func (c ShareLink) Revoke(linkID int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	link, err := models.GetShareLinkByID(c.Log, linkID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	_, redirectURL, err := getShareLinkTarget(c.Log, intUserID, link.ItemID, link.AlbumID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		if redirectURL == "" {
+			return c.Redirect(Home.Index)
+		}
+		return c.Redirect(redirectURL)
+	}
+
+	err = link.Revoke(c.Log)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(redirectURL)
+	}
+
+	c.Flash.Success("Share link revoked")
+	return c.Redirect(redirectURL)
+}
+
+// Details is the GET action for the access log of a share link
+func (c ShareLink) Details(id int64) revel.Result {
+	userID := c.Flash.Out["userID"]
+	loggedInUser := c.Flash.Out["loggedInUser"]
+	c.Flash.Out["loggedInUser"] = loggedInUser
+
+	intUserID, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		c.Log.Errorf("Invalid User ID found in session - %s. Error: %s", userID, err.Error())
+		c.Flash.Error("Please login to continue")
+		return c.Redirect(Account.Index)
+	}
+
+	link, err := models.GetShareLinkByID(c.Log, id)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		return c.Redirect(Home.Index)
+	}
+
+	_, redirectURL, err := getShareLinkTarget(c.Log, intUserID, link.ItemID, link.AlbumID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+		if redirectURL == "" {
+			return c.Redirect(Home.Index)
+		}
+		return c.Redirect(redirectURL)
+	}
+
+	accesses, err := models.GetShareLinkAccessLog(c.Log, link.LinkID)
+	if err != nil {
+		c.Flash.Error(err.Error())
+	}
+
+	shareLinkDetails := &models.ShareLinkDetails{
+		Link:     link,
+		Accesses: accesses,
+	}
+
+	return c.Render(shareLinkDetails)
+}
+
+// getShareLinkTarget checks whether the user can manage the share links of the item (or the album if itemID is 0)
+// The owner of the item or the album and the leaders of its group can manage the share links
+// The group of the item or the album is returned along with the URL of its page. The pending items cannot be shared
+func getShareLinkTarget(log logger.MultiLogger, userID, itemID, albumID int64) (int64, string, error) {
+	var ownerID, groupID int64
+	var redirectURL string
+	if itemID > 0 {
+		item, err := models.GetItemDetailsByID(log, itemID)
+		if err != nil || !item.Uploaded {
+			return 0, "", fmt.Errorf("Item details unavailable")
+		}
+		redirectURL = fmt.Sprintf("/item/%d", itemID)
+		if item.IsPending() {
+			return 0, redirectURL, fmt.Errorf("The item is pending for approval and cannot be shared through a link")
+		}
+		ownerID, groupID = item.CreatedBy, item.GroupID
+	} else {
+		album, err := models.GetAlbumByID(log, albumID)
+		if err != nil {
+			return 0, "", err
+		}
+		ownerID, groupID = album.CreatedBy, album.GroupID
+		redirectURL = fmt.Sprintf("/albums/%d", albumID)
+	}
+
+	canManage, err := canManageShareLinks(log, userID, ownerID, groupID)
+	if err != nil {
+		return 0, redirectURL, err
+	}
+	if !canManage {
+		return 0, redirectURL, fmt.Errorf("Unauthorized. You do not have enough permissions to manage the share links.")
+	}
+
+	return groupID, redirectURL, nil
+}
+
+// canManageShareLinks checks whether the user is the owner of the item or the album, or a leader of its group
+func canManageShareLinks(log logger.MultiLogger, userID, ownerID, groupID int64) (bool, error) {
+	if userID == ownerID {
+		return true, nil
+	}
+
+	return hasGroupPermission(log, userID, groupID, common.PermissionManageMembers)
+}
@@ -0,0 +1,22 @@
+-- Adds the group-scoped tags of the items to an existing database
+CREATE TABLE Tags (
+    tag_id serial,
+    tag_name text not null,
+    group_id integer not null,
+    created_by integer not null,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    FOREIGN KEY (group_id) references Groups(group_id),
+    UNIQUE (group_id, tag_name),
+    PRIMARY KEY (tag_id)
+);
+
+CREATE TABLE ItemTags (
+    item_id integer not null,
+    tag_id integer not null,
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) references Tags(tag_id) ON DELETE CASCADE,
+    PRIMARY KEY (item_id, tag_id)
+);
+
+CREATE INDEX idx_ItemTags_TagID ON ItemTags(tag_id);
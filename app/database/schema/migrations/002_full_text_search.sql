@@ -0,0 +1,34 @@
+-- Adds the full-text search columns to an existing database
+ALTER TABLE Items ADD COLUMN search_vector tsvector;
+ALTER TABLE Comments ADD COLUMN search_vector tsvector;
+
+CREATE FUNCTION items_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.item_name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION comments_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := to_tsvector('english', coalesce(NEW.comment, ''));
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_Items_SearchVector BEFORE INSERT OR UPDATE OF item_name, description ON Items
+    FOR EACH ROW EXECUTE PROCEDURE items_search_vector_update();
+
+CREATE TRIGGER trg_Comments_SearchVector BEFORE INSERT OR UPDATE OF comment ON Comments
+    FOR EACH ROW EXECUTE PROCEDURE comments_search_vector_update();
+
+-- Backfill the existing rows
+UPDATE Items SET
+    search_vector = setweight(to_tsvector('english', coalesce(item_name, '')), 'A') ||
+                    setweight(to_tsvector('english', coalesce(description, '')), 'B');
+UPDATE Comments SET search_vector = to_tsvector('english', coalesce(comment, ''));
+
+CREATE INDEX idx_Items_SearchVector ON Items USING GIN (search_vector);
+CREATE INDEX idx_Comments_SearchVector ON Comments USING GIN (search_vector);
@@ -0,0 +1,31 @@
+-- Adds the albums of the groups to an existing database
+-- The share links of the albums (015_share_links.sql) depend on this script
+CREATE TABLE Albums (
+    album_id serial,
+    album_name text not null,
+    group_id integer not null,
+    cover_item_id integer,
+    position integer not null default 0,
+    created_by integer not null,
+    creation_time timestamptz NOT NULL default now(),
+    last_updated timestamptz,
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    FOREIGN KEY (group_id) references Groups(group_id),
+    FOREIGN KEY (cover_item_id) references Items(item_id) ON DELETE SET NULL,
+    UNIQUE (group_id, album_name),
+    PRIMARY KEY (album_id)
+);
+
+CREATE TABLE AlbumItems (
+    album_id integer not null,
+    item_id integer not null,
+    position integer not null default 0,
+    added_by integer not null,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (album_id) references Albums(album_id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (added_by) references AppUser(user_id),
+    PRIMARY KEY (album_id, item_id)
+);
+
+CREATE INDEX idx_AlbumItems_ItemID ON AlbumItems(item_id);
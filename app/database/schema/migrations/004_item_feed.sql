@@ -0,0 +1,8 @@
+-- Adds the comment count and the feed indexes to an existing database
+ALTER TABLE Items ADD COLUMN comment_count integer NOT NULL default 0;
+
+UPDATE Items SET comment_count = (SELECT count(*) FROM Comments WHERE Comments.item_id = Items.item_id);
+
+CREATE INDEX idx_Items_Feed_CreationTime ON Items(creation_time, item_id) WHERE uploaded = true;
+CREATE INDEX idx_Items_Feed_GroupID_CreationTime ON Items(group_id, creation_time, item_id) WHERE uploaded = true;
+CREATE INDEX idx_Items_Feed_CommentCount ON Items(comment_count, item_id) WHERE uploaded = true;
@@ -0,0 +1,2 @@
+-- Adds the index for filtering the group gallery by uploader to an existing database
+CREATE INDEX idx_Items_Feed_GroupID_CreatedBy ON Items(group_id, created_by, creation_time, item_id) WHERE uploaded = true;
@@ -0,0 +1,4 @@
+-- Adds threaded replies, edits and soft deletes of comments to an existing database
+ALTER TABLE Comments ADD COLUMN parent_comment_id integer references Comments(comment_id);
+ALTER TABLE Comments ADD COLUMN is_deleted boolean NOT NULL default false;
+ALTER TABLE Comments ADD COLUMN last_updated timestamptz;
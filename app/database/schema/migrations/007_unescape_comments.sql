@@ -0,0 +1,5 @@
+-- Comments were stored HTML escaped, they are now stored as raw text and sanitized when rendered
+-- &amp; is replaced last so that the entities typed by the users are restored correctly
+UPDATE Comments SET comment =
+    replace(replace(replace(replace(replace(comment,
+        '&lt;', '<'), '&gt;', '>'), '&#39;', ''''), '&#34;', '"'), '&amp;', '&');
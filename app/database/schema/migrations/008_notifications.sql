@@ -0,0 +1,19 @@
+-- Adds the notifications of @mentions to an existing database
+CREATE TABLE Notifications (
+    notification_id serial,
+    user_id integer NOT NULL,
+    actor_id integer NOT NULL,
+    notification_type integer NOT NULL,
+    item_id integer NOT NULL,
+    comment_id integer,
+    is_read boolean NOT NULL default false,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (actor_id) references AppUser(user_id),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) references Comments(comment_id) ON DELETE CASCADE,
+    PRIMARY KEY (notification_id)
+);
+
+CREATE INDEX idx_Notifications_UserID ON Notifications(user_id, creation_time);
+CREATE INDEX idx_Notifications_Unread ON Notifications(user_id) WHERE is_read = false;
@@ -0,0 +1,18 @@
+-- Adds the reactions on items and comments to an existing database
+CREATE TABLE Reactions (
+    reaction_id serial,
+    item_id integer NOT NULL,
+    comment_id integer,
+    user_id integer NOT NULL,
+    emoji text NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) references Comments(comment_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    PRIMARY KEY (reaction_id)
+);
+
+-- A user can add each emoji once per item and once per comment
+CREATE UNIQUE INDEX idx_Reactions_Item ON Reactions(item_id, user_id, emoji) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX idx_Reactions_Comment ON Reactions(comment_id, user_id, emoji) WHERE comment_id IS NOT NULL;
+CREATE INDEX idx_Reactions_ItemID ON Reactions(item_id);
@@ -0,0 +1,11 @@
+-- Adds the favorite items of the users to an existing database
+CREATE TABLE Favorites (
+    user_id integer NOT NULL,
+    item_id integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, item_id)
+);
+
+CREATE INDEX idx_Favorites_UserID ON Favorites(user_id, creation_time);
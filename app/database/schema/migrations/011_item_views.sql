@@ -0,0 +1,12 @@
+-- Adds the views of the items to an existing database
+CREATE TABLE ItemViews (
+    view_id serial,
+    item_id integer NOT NULL,
+    user_id integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    PRIMARY KEY (view_id)
+);
+
+CREATE INDEX idx_ItemViews_ItemID ON ItemViews(item_id, user_id, creation_time);
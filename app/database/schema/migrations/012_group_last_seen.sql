@@ -0,0 +1,9 @@
+-- Adds the last seen time of the users per group to an existing database
+CREATE TABLE GroupLastSeen (
+    user_id integer NOT NULL,
+    group_id integer NOT NULL,
+    last_seen timestamptz NOT NULL default now(),
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, group_id)
+);
@@ -0,0 +1,19 @@
+-- Adds the activity feed of the groups to an existing database
+CREATE TABLE GroupEvents (
+    event_id serial,
+    group_id integer NOT NULL,
+    actor_id integer NOT NULL,
+    event_type integer NOT NULL,
+    item_id integer,
+    item_name text,
+    target_user_id integer,
+    details text,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) references AppUser(user_id),
+    FOREIGN KEY (target_user_id) references AppUser(user_id),
+    PRIMARY KEY (event_id)
+);
+
+-- item_id is not a foreign key since the events of deleted items are kept
+CREATE INDEX idx_GroupEvents_GroupID ON GroupEvents(group_id, event_id);
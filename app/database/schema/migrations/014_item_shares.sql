@@ -0,0 +1,18 @@
+-- Adds sharing of the items into multiple groups to an existing database
+ALTER TABLE Items ADD COLUMN comment_scope text NOT NULL default 'shared';
+
+-- group_id is the group the comment was posted in, NULL for the comments posted before the items could be shared
+ALTER TABLE Comments ADD COLUMN group_id integer references Groups(group_id);
+
+CREATE TABLE ItemShares (
+    item_id integer NOT NULL,
+    group_id integer NOT NULL,
+    shared_by integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (shared_by) references AppUser(user_id),
+    PRIMARY KEY (item_id, group_id)
+);
+
+CREATE INDEX idx_ItemShares_GroupID ON ItemShares(group_id, item_id);
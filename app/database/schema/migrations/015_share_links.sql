@@ -0,0 +1,38 @@
+-- Adds the public share links and their access log to an existing database
+CREATE TABLE ShareLinks (
+    link_id serial,
+    token text NOT NULL,
+    item_id integer,
+    album_id integer,
+    group_id integer NOT NULL,
+    password_hash text,
+    allow_download boolean NOT NULL default false,
+    expires_at timestamptz,
+    is_revoked boolean NOT NULL default false,
+    created_by integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) references Albums(album_id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    CHECK ((item_id IS NULL) <> (album_id IS NULL)),
+    UNIQUE (token),
+    PRIMARY KEY (link_id)
+);
+
+CREATE INDEX idx_ShareLinks_ItemID ON ShareLinks(item_id);
+CREATE INDEX idx_ShareLinks_AlbumID ON ShareLinks(album_id);
+
+CREATE TABLE ShareLinkAccess (
+    access_id serial,
+    link_id integer NOT NULL,
+    item_id integer,
+    action text NOT NULL,
+    ip_address text,
+    user_agent text,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (link_id) references ShareLinks(link_id) ON DELETE CASCADE,
+    PRIMARY KEY (access_id)
+);
+
+CREATE INDEX idx_ShareLinkAccess_LinkID ON ShareLinkAccess(link_id, access_id);
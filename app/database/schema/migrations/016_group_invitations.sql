@@ -0,0 +1,22 @@
+-- Adds the group invitations to an existing database
+CREATE TABLE GroupInvitations (
+    invitation_id serial,
+    token text NOT NULL,
+    group_id integer NOT NULL,
+    user_id integer,
+    email text,
+    invited_by integer NOT NULL,
+    status integer NOT NULL default 0,
+    expires_at timestamptz NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    responded_at timestamptz,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (invited_by) references AppUser(user_id),
+    CHECK (user_id IS NOT NULL OR email IS NOT NULL),
+    UNIQUE (token),
+    PRIMARY KEY (invitation_id)
+);
+
+CREATE INDEX idx_GroupInvitations_GroupID ON GroupInvitations(group_id, status);
+CREATE INDEX idx_GroupInvitations_UserID ON GroupInvitations(user_id, status);
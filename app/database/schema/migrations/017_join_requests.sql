@@ -0,0 +1,24 @@
+-- Adds the requests to join the groups to an existing database
+-- Notifications can now be about a group instead of an item
+ALTER TABLE Notifications ALTER COLUMN item_id DROP NOT NULL;
+ALTER TABLE Notifications ADD COLUMN group_id integer;
+ALTER TABLE Notifications ADD FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE;
+
+CREATE TABLE GroupJoinRequests (
+    request_id serial,
+    group_id integer NOT NULL,
+    user_id integer NOT NULL,
+    message text,
+    workflow_status integer NOT NULL default 0,
+    handled_by integer,
+    creation_time timestamptz NOT NULL default now(),
+    handled_at timestamptz,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (handled_by) references AppUser(user_id),
+    PRIMARY KEY (request_id)
+);
+
+-- Only one pending request per user and group
+CREATE UNIQUE INDEX idx_GroupJoinRequests_Pending ON GroupJoinRequests(group_id, user_id) WHERE workflow_status = 0;
+CREATE INDEX idx_GroupJoinRequests_UserID ON GroupJoinRequests(user_id, creation_time);
@@ -0,0 +1,5 @@
+-- Replaces the leader flag of the group members with a role to an existing database
+-- Roles: 1 - Viewer, 2 - Contributor, 3 - Moderator, 4 - Leader
+ALTER TABLE UserGroupMap ADD COLUMN role integer NOT NULL default 2;
+UPDATE UserGroupMap SET role = 4 WHERE is_leader = true;
+ALTER TABLE UserGroupMap DROP COLUMN is_leader;
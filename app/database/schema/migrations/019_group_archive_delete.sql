@@ -0,0 +1,22 @@
+-- Adds archiving of the groups and the progress of the group deletions to an existing database
+ALTER TABLE Groups ADD COLUMN is_archived boolean NOT NULL default false;
+
+-- group_id is not a foreign key since the progress of a deletion is kept after the group is removed
+CREATE TABLE GroupDeletions (
+    deletion_id serial,
+    group_id integer NOT NULL,
+    group_name text NOT NULL,
+    requested_by integer NOT NULL,
+    status integer NOT NULL default 0,
+    total_items integer NOT NULL default 0,
+    deleted_items integer NOT NULL default 0,
+    failed_files integer NOT NULL default 0,
+    error text,
+    creation_time timestamptz NOT NULL default now(),
+    finished_at timestamptz,
+    FOREIGN KEY (requested_by) references AppUser(user_id),
+    PRIMARY KEY (deletion_id)
+);
+
+-- Only one running deletion per group
+CREATE UNIQUE INDEX idx_GroupDeletions_Running ON GroupDeletions(group_id) WHERE status = 0;
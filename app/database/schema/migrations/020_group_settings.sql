@@ -0,0 +1,17 @@
+-- Adds the per group settings and the approval of the uploads to an existing database
+ALTER TABLE Items ADD COLUMN approval_status integer NOT NULL default 0;
+
+-- The defaults apply to the groups without a row
+-- An empty allowed_item_types allows all the item types
+CREATE TABLE GroupSettings (
+    group_id integer NOT NULL,
+    allowed_item_types integer[] NOT NULL default '{}',
+    comments_enabled boolean NOT NULL default true,
+    upload_approval boolean NOT NULL default false,
+    default_sort text NOT NULL default 'newest',
+    updated_by integer,
+    last_updated timestamptz,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) references AppUser(user_id),
+    PRIMARY KEY (group_id)
+);
@@ -0,0 +1,2 @@
+-- Adds the details of the notifications (e.g. the reason an upload was rejected) to an existing database
+ALTER TABLE Notifications ADD COLUMN details text;
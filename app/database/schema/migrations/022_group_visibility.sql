@@ -0,0 +1,2 @@
+-- Adds the visibility of the groups to an existing database, the existing groups stay hidden
+ALTER TABLE Groups ADD COLUMN visibility integer NOT NULL default 0;
@@ -0,0 +1,6 @@
+-- Adds the parent of the groups to an existing database, the existing groups stay at the top level
+-- The subgroups of a deleted group are moved to the top level
+ALTER TABLE Groups ADD COLUMN parent_group_id integer;
+ALTER TABLE Groups ADD COLUMN inherit_members boolean NOT NULL default false;
+ALTER TABLE Groups ADD FOREIGN KEY (parent_group_id) references Groups(group_id) ON DELETE SET NULL;
+CREATE INDEX idx_Groups_ParentGroupID ON Groups(parent_group_id);
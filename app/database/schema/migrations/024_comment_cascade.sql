@@ -0,0 +1,7 @@
+-- Deletes the comments of an item along with the item in an existing database
+-- The comments are soft deleted on their own, so the rows stay until the item is deleted. The replies
+-- of a comment deleted along with its group are kept as top level comments
+ALTER TABLE Comments DROP CONSTRAINT comments_item_id_fkey;
+ALTER TABLE Comments ADD CONSTRAINT comments_item_id_fkey FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE;
+ALTER TABLE Comments DROP CONSTRAINT comments_parent_comment_id_fkey;
+ALTER TABLE Comments ADD CONSTRAINT comments_parent_comment_id_fkey FOREIGN KEY (parent_comment_id) references Comments(comment_id) ON DELETE SET NULL;
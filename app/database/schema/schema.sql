@@ -23,14 +23,20 @@ CREATE TABLE Groups (
     workflow_status integer not null default 0,
     max_item_count integer NOT NULL,
     max_item_space float(3) NOT NULL,
-    FOREIGN KEY (created_by) references AppUser(user_id)
+    is_archived boolean NOT NULL default false,
+    visibility integer NOT NULL default 0,
+    parent_group_id integer,
+    inherit_members boolean NOT NULL default false,
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    FOREIGN KEY (parent_group_id) references Groups(group_id) ON DELETE SET NULL
 );
+CREATE INDEX idx_Groups_ParentGroupID ON Groups(parent_group_id);
 
 CREATE TABLE UserGroupMap (
     user_id integer not null ,
     group_id integer not null,
     created_by integer not null,
-    is_leader boolean not null default false,
+    role integer not null default 2,
     creation_time timestamptz NOT NULL default now(),
     last_updated timestamptz,
     workflow_status integer not null default 0,
@@ -62,6 +68,10 @@ CREATE TABLE Items (
     created_by integer NOT NULL,
     creation_time timestamptz NOT NULL default now(),
     last_accessed timestamptz,
+    comment_count integer NOT NULL default 0,
+    comment_scope text NOT NULL default 'shared',
+    approval_status integer NOT NULL default 0,
+    search_vector tsvector,
     FOREIGN KEY (created_by) references AppUser(user_id),
     FOREIGN KEY (group_id) references Groups(group_id),
     FOREIGN KEY (item_type_id) references ItemTypes(item_type_id),
@@ -72,11 +82,322 @@ CREATE TABLE Comments (
     comment_id serial,
     comment text not null,
     item_id integer not null,
+    parent_comment_id integer,
+    is_deleted boolean NOT NULL default false,
     created_by integer not null,
     creation_time timestamptz NOT NULL default now(),
+    last_updated timestamptz,
+    group_id integer,
+    search_vector tsvector,
     FOREIGN KEY (created_by) references AppUser(user_id),
-    FOREIGN KEY (item_id) references Items(item_id),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_comment_id) references Comments(comment_id) ON DELETE SET NULL,
+    FOREIGN KEY (group_id) references Groups(group_id),
     PRIMARY KEY (comment_id)
 );
 
-CREATE INDEX idx_Comments_ItemID  ON Comments(item_id);
\ No newline at end of file
+CREATE INDEX idx_Comments_ItemID  ON Comments(item_id);
+
+-- Indexes for the keyset paginated item feed (only uploaded items are listed)
+CREATE INDEX idx_Items_Feed_CreationTime ON Items(creation_time, item_id) WHERE uploaded = true;
+CREATE INDEX idx_Items_Feed_GroupID_CreationTime ON Items(group_id, creation_time, item_id) WHERE uploaded = true;
+CREATE INDEX idx_Items_Feed_CommentCount ON Items(comment_count, item_id) WHERE uploaded = true;
+CREATE INDEX idx_Items_Feed_GroupID_CreatedBy ON Items(group_id, created_by, creation_time, item_id) WHERE uploaded = true;
+
+CREATE TABLE Tags (
+    tag_id serial,
+    tag_name text not null,
+    group_id integer not null,
+    created_by integer not null,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    FOREIGN KEY (group_id) references Groups(group_id),
+    UNIQUE (group_id, tag_name),
+    PRIMARY KEY (tag_id)
+);
+
+CREATE TABLE ItemTags (
+    item_id integer not null,
+    tag_id integer not null,
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) references Tags(tag_id) ON DELETE CASCADE,
+    PRIMARY KEY (item_id, tag_id)
+);
+
+CREATE INDEX idx_ItemTags_TagID ON ItemTags(tag_id);
+
+
+-- Full-text search
+CREATE FUNCTION items_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.item_name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION comments_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := to_tsvector('english', coalesce(NEW.comment, ''));
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_Items_SearchVector BEFORE INSERT OR UPDATE OF item_name, description ON Items
+    FOR EACH ROW EXECUTE PROCEDURE items_search_vector_update();
+
+CREATE TRIGGER trg_Comments_SearchVector BEFORE INSERT OR UPDATE OF comment ON Comments
+    FOR EACH ROW EXECUTE PROCEDURE comments_search_vector_update();
+
+CREATE INDEX idx_Items_SearchVector ON Items USING GIN (search_vector);
+CREATE INDEX idx_Comments_SearchVector ON Comments USING GIN (search_vector);
+
+CREATE TABLE Albums (
+    album_id serial,
+    album_name text not null,
+    group_id integer not null,
+    cover_item_id integer,
+    position integer not null default 0,
+    created_by integer not null,
+    creation_time timestamptz NOT NULL default now(),
+    last_updated timestamptz,
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    FOREIGN KEY (group_id) references Groups(group_id),
+    FOREIGN KEY (cover_item_id) references Items(item_id) ON DELETE SET NULL,
+    UNIQUE (group_id, album_name),
+    PRIMARY KEY (album_id)
+);
+
+CREATE TABLE AlbumItems (
+    album_id integer not null,
+    item_id integer not null,
+    position integer not null default 0,
+    added_by integer not null,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (album_id) references Albums(album_id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (added_by) references AppUser(user_id),
+    PRIMARY KEY (album_id, item_id)
+);
+
+CREATE INDEX idx_AlbumItems_ItemID ON AlbumItems(item_id);
+
+CREATE TABLE Notifications (
+    notification_id serial,
+    user_id integer NOT NULL,
+    actor_id integer NOT NULL,
+    notification_type integer NOT NULL,
+    item_id integer,
+    comment_id integer,
+    group_id integer,
+    details text,
+    is_read boolean NOT NULL default false,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (actor_id) references AppUser(user_id),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) references Comments(comment_id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    PRIMARY KEY (notification_id)
+);
+
+CREATE INDEX idx_Notifications_UserID ON Notifications(user_id, creation_time);
+CREATE INDEX idx_Notifications_Unread ON Notifications(user_id) WHERE is_read = false;
+
+CREATE TABLE Reactions (
+    reaction_id serial,
+    item_id integer NOT NULL,
+    comment_id integer,
+    user_id integer NOT NULL,
+    emoji text NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) references Comments(comment_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    PRIMARY KEY (reaction_id)
+);
+
+-- A user can add each emoji once per item and once per comment
+CREATE UNIQUE INDEX idx_Reactions_Item ON Reactions(item_id, user_id, emoji) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX idx_Reactions_Comment ON Reactions(comment_id, user_id, emoji) WHERE comment_id IS NOT NULL;
+CREATE INDEX idx_Reactions_ItemID ON Reactions(item_id);
+
+CREATE TABLE Favorites (
+    user_id integer NOT NULL,
+    item_id integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, item_id)
+);
+
+CREATE INDEX idx_Favorites_UserID ON Favorites(user_id, creation_time);
+
+CREATE TABLE ItemViews (
+    view_id serial,
+    item_id integer NOT NULL,
+    user_id integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    PRIMARY KEY (view_id)
+);
+
+CREATE INDEX idx_ItemViews_ItemID ON ItemViews(item_id, user_id, creation_time);
+
+CREATE TABLE GroupLastSeen (
+    user_id integer NOT NULL,
+    group_id integer NOT NULL,
+    last_seen timestamptz NOT NULL default now(),
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, group_id)
+);
+
+CREATE TABLE GroupEvents (
+    event_id serial,
+    group_id integer NOT NULL,
+    actor_id integer NOT NULL,
+    event_type integer NOT NULL,
+    item_id integer,
+    item_name text,
+    target_user_id integer,
+    details text,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) references AppUser(user_id),
+    FOREIGN KEY (target_user_id) references AppUser(user_id),
+    PRIMARY KEY (event_id)
+);
+
+-- item_id is not a foreign key since the events of deleted items are kept
+CREATE INDEX idx_GroupEvents_GroupID ON GroupEvents(group_id, event_id);
+
+-- The stored file of a shared item is reused, only the primary group (Items.group_id) is charged for the space
+CREATE TABLE ItemShares (
+    item_id integer NOT NULL,
+    group_id integer NOT NULL,
+    shared_by integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (shared_by) references AppUser(user_id),
+    PRIMARY KEY (item_id, group_id)
+);
+
+CREATE INDEX idx_ItemShares_GroupID ON ItemShares(group_id, item_id);
+
+CREATE TABLE ShareLinks (
+    link_id serial,
+    token text NOT NULL,
+    item_id integer,
+    album_id integer,
+    group_id integer NOT NULL,
+    password_hash text,
+    allow_download boolean NOT NULL default false,
+    expires_at timestamptz,
+    is_revoked boolean NOT NULL default false,
+    created_by integer NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (item_id) references Items(item_id) ON DELETE CASCADE,
+    FOREIGN KEY (album_id) references Albums(album_id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) references AppUser(user_id),
+    CHECK ((item_id IS NULL) <> (album_id IS NULL)),
+    UNIQUE (token),
+    PRIMARY KEY (link_id)
+);
+
+CREATE INDEX idx_ShareLinks_ItemID ON ShareLinks(item_id);
+CREATE INDEX idx_ShareLinks_AlbumID ON ShareLinks(album_id);
+
+CREATE TABLE ShareLinkAccess (
+    access_id serial,
+    link_id integer NOT NULL,
+    item_id integer,
+    action text NOT NULL,
+    ip_address text,
+    user_agent text,
+    creation_time timestamptz NOT NULL default now(),
+    FOREIGN KEY (link_id) references ShareLinks(link_id) ON DELETE CASCADE,
+    PRIMARY KEY (access_id)
+);
+
+CREATE INDEX idx_ShareLinkAccess_LinkID ON ShareLinkAccess(link_id, access_id);
+
+CREATE TABLE GroupInvitations (
+    invitation_id serial,
+    token text NOT NULL,
+    group_id integer NOT NULL,
+    user_id integer,
+    email text,
+    invited_by integer NOT NULL,
+    status integer NOT NULL default 0,
+    expires_at timestamptz NOT NULL,
+    creation_time timestamptz NOT NULL default now(),
+    responded_at timestamptz,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (invited_by) references AppUser(user_id),
+    CHECK (user_id IS NOT NULL OR email IS NOT NULL),
+    UNIQUE (token),
+    PRIMARY KEY (invitation_id)
+);
+
+CREATE INDEX idx_GroupInvitations_GroupID ON GroupInvitations(group_id, status);
+CREATE INDEX idx_GroupInvitations_UserID ON GroupInvitations(user_id, status);
+
+CREATE TABLE GroupJoinRequests (
+    request_id serial,
+    group_id integer NOT NULL,
+    user_id integer NOT NULL,
+    message text,
+    workflow_status integer NOT NULL default 0,
+    handled_by integer,
+    creation_time timestamptz NOT NULL default now(),
+    handled_at timestamptz,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) references AppUser(user_id),
+    FOREIGN KEY (handled_by) references AppUser(user_id),
+    PRIMARY KEY (request_id)
+);
+
+-- Only one pending request per user and group
+CREATE UNIQUE INDEX idx_GroupJoinRequests_Pending ON GroupJoinRequests(group_id, user_id) WHERE workflow_status = 0;
+CREATE INDEX idx_GroupJoinRequests_UserID ON GroupJoinRequests(user_id, creation_time);
+
+-- group_id is not a foreign key since the progress of a deletion is kept after the group is removed
+CREATE TABLE GroupDeletions (
+    deletion_id serial,
+    group_id integer NOT NULL,
+    group_name text NOT NULL,
+    requested_by integer NOT NULL,
+    status integer NOT NULL default 0,
+    total_items integer NOT NULL default 0,
+    deleted_items integer NOT NULL default 0,
+    failed_files integer NOT NULL default 0,
+    error text,
+    creation_time timestamptz NOT NULL default now(),
+    finished_at timestamptz,
+    FOREIGN KEY (requested_by) references AppUser(user_id),
+    PRIMARY KEY (deletion_id)
+);
+
+-- Only one running deletion per group
+CREATE UNIQUE INDEX idx_GroupDeletions_Running ON GroupDeletions(group_id) WHERE status = 0;
+
+-- Settings of the groups, the defaults apply to the groups without a row
+-- An empty allowed_item_types allows all the item types
+CREATE TABLE GroupSettings (
+    group_id integer NOT NULL,
+    allowed_item_types integer[] NOT NULL default '{}',
+    comments_enabled boolean NOT NULL default true,
+    upload_approval boolean NOT NULL default false,
+    default_sort text NOT NULL default 'newest',
+    updated_by integer,
+    last_updated timestamptz,
+    FOREIGN KEY (group_id) references Groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) references AppUser(user_id),
+    PRIMARY KEY (group_id)
+);
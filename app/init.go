@@ -1,8 +1,6 @@
 package app
 
 import (
-	"html"
-
 	"github.com/revel/revel"
 	"github.com/sp-share/app/auth"
 	"github.com/sp-share/app/common"
@@ -42,6 +40,14 @@ func init() {
 	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Requests{})
 	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Item{})
 	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Limit{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Search{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Album{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Notification{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Favorite{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Activity{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.ShareLink{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.Invitation{})
+	revel.InterceptFunc(auth.Authenticate, revel.BEFORE, &controllers.JoinRequest{})
 
 	revel.TemplateFuncs["increment"] = func(a int) int {
 		return a + 1
@@ -77,9 +83,10 @@ func init() {
 		return false
 	}
 
-	revel.TemplateFuncs["unescape"] = func(str string) string {
-		return html.UnescapeString(str)
-	}
+	// richtext renders the Markdown subset of user entered text as sanitized HTML
+	revel.TemplateFuncs["richtext"] = common.RenderRichText
+
+	revel.OnAppStart(loadReactions)
 
 	// Register startup functions with OnAppStart
 	// revel.DevMode and revel.RunMode only work inside of OnAppStart. See Example Startup Script
@@ -108,3 +115,8 @@ var HeaderFilter = func(c *revel.Controller, fc []revel.Filter) {
 //		// Dev mode
 //	}
 //}
+
+// loadReactions loads the set of reactions allowed on the items and comments from app.conf
+func loadReactions() {
+	common.SetReactions(revel.Config.StringDefault("reactions", common.DefaultReactions))
+}
@@ -0,0 +1,481 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// Album is the model for the albums created within a group
+type Album struct {
+	tableName    struct{}  `sql:"Albums,alias:album"`
+	AlbumID      int64     `sql:"album_id,pk"`
+	AlbumName    string    `sql:"album_name"`
+	GroupID      int64     `sql:"group_id"`
+	CoverItemID  int64     `sql:"cover_item_id"`
+	Position     int       `sql:"position"`
+	CreatedBy    int64     `sql:"created_by"`
+	CreationTime time.Time `sql:"creation_time"`
+	LastUpdated  time.Time `sql:"last_updated"`
+}
+
+// AlbumItem holds the mapping of albums and items
+type AlbumItem struct {
+	tableName    struct{}  `sql:"AlbumItems,alias:ai"`
+	AlbumID      int64     `sql:"album_id,pk"`
+	ItemID       int64     `sql:"item_id,pk"`
+	Position     int       `sql:"position"`
+	AddedBy      int64     `sql:"added_by"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// AlbumView is the display model for an album
+type AlbumView struct {
+	tableName     struct{}  `sql:"Albums,alias:album"`
+	AlbumID       int64     `sql:"album_id,pk"`
+	AlbumName     string    `sql:"album_name"`
+	GroupID       int64     `sql:"group_id"`
+	CoverItemID   int64     `sql:"cover_item_id"`
+	CoverItemPath string    `sql:"cover_item_path"`
+	Position      int       `sql:"position"`
+	ItemCount     int       `sql:"item_count"`
+	CreationTime  time.Time `sql:"creation_time"`
+}
+
+// AlbumDetails is the view model for the album page
+type AlbumDetails struct {
+	Album      *Album
+	GroupName  string
+	Items      []*ItemView
+	ShareLinks *ShareLinkList
+}
+
+// AlbumNavigation holds the previous and next items of an item within an album
+type AlbumNavigation struct {
+	AlbumID      int64
+	AlbumName    string
+	PrevItemID   int64 `sql:"prev_item_id"`
+	NextItemID   int64 `sql:"next_item_id"`
+	ItemPosition int   `sql:"item_position"`
+	ItemCount    int   `sql:"item_count"`
+}
+
+// Add creates a new album at the end of the group's album list
+func (model *Album) Add(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).
+		Value("position", "(SELECT coalesce(max(position), 0) + 1 FROM albums WHERE group_id = ?)", model.GroupID).
+		Returning("*").
+		OnConflict("DO NOTHING").
+		Insert()
+	if err != nil {
+		log.Errorf("Unable to insert album into database. Err: %s", err.Error())
+		return fmt.Errorf("Unable to create the album at the moment")
+	}
+
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("An album with the name '%s' already exists in the group", model.AlbumName)
+	}
+
+	return nil
+}
+
+// Rename updates the name of the album
+func (model *Album) Rename(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	// Check for another album with the same name
+	count, err := client.GetPGClient().Model((*Album)(nil)).
+		Where("group_id = ?", model.GroupID).
+		Where("album_name = ?", model.AlbumName).
+		Where("album_id <> ?", model.AlbumID).
+		Count()
+	if err != nil {
+		log.Errorf("Unable to check the album names of the group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to rename the album at the moment")
+	}
+	if count > 0 {
+		return fmt.Errorf("An album with the name '%s' already exists in the group", model.AlbumName)
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("album_name = ?", model.AlbumName).
+		Set("last_updated = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to rename the album (ID: %d). Err: %s", model.AlbumID, err.Error())
+		return fmt.Errorf("Unable to rename the album at the moment")
+	}
+
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to rename the album at the moment")
+	}
+
+	return nil
+}
+
+// GetAlbumByID returns the album stored in the database for the given album ID
+func GetAlbumByID(log logger.MultiLogger, albumID int64) (*Album, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	album := &Album{
+		AlbumID: albumID,
+	}
+	err = client.GetPGClient().Select(album)
+	if err != nil {
+		log.Errorf("Unable to get the album (ID: %d). Err: %s", albumID, err.Error())
+		return nil, fmt.Errorf("The album does not exist")
+	}
+
+	return album, nil
+}
+
+// GetAlbumsForGroup returns all the albums of a group in their display order
+func GetAlbumsForGroup(log logger.MultiLogger, groupID int64) ([]*AlbumView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var albums []*AlbumView
+	err = client.GetPGClient().Model(&albums).
+		ColumnExpr(`"album".album_id, "album".album_name, "album".group_id, "album".cover_item_id`).
+		ColumnExpr(`"album".position, "album".creation_time, ci.item_path AS cover_item_path`).
+		ColumnExpr(`(SELECT count(*) FROM albumitems AS ai WHERE ai.album_id = "album".album_id) AS item_count`).
+		Join("LEFT JOIN items AS ci").
+		JoinOn("ci.item_id = \"album\".cover_item_id").
+		Where("\"album\".group_id = ?", groupID).
+		OrderExpr(`"album".position ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the albums of the group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the albums of the group")
+	}
+
+	return albums, nil
+}
+
+// GetAlbumsForItem returns all the albums containing the given item
+func GetAlbumsForItem(log logger.MultiLogger, itemID int64) ([]*Album, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var albums []*Album
+	err = client.GetPGClient().Model(&albums).
+		Join("JOIN albumitems AS ai").
+		JoinOn("ai.album_id = \"album\".album_id").
+		Where("ai.item_id = ?", itemID).
+		OrderExpr(`"album".position ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the albums of the item - %d. Err: %s", itemID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the albums of the item")
+	}
+
+	return albums, nil
+}
+
+// GetAlbumItems returns all the uploaded items of an album in their display order
+func GetAlbumItems(log logger.MultiLogger, albumID int64) ([]*ItemView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var items []*ItemView
+	err = client.GetPGClient().Model(&items).
+		ColumnExpr(itemViewColumns).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"item\".created_by").
+		Join("JOIN albumitems AS ai").
+		JoinOn("ai.item_id = \"item\".item_id").
+		Where("ai.album_id = ?", albumID).
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		OrderExpr("ai.position ASC").
+		OrderExpr(`"item".item_id ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the items of the album - %d. Err: %s", albumID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the items of the album")
+	}
+
+	return items, nil
+}
+
+// MoveAlbum moves the album one place up or down in the group's album list
+func (model *Album) MoveAlbum(log logger.MultiLogger, up bool) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		// Find the neighbouring album
+		neighbour := &Album{}
+		query := tx.Model(neighbour).Where("group_id = ?", model.GroupID)
+		if up {
+			query = query.Where("position < ?", model.Position).Order("position DESC")
+		} else {
+			query = query.Where("position > ?", model.Position).Order("position ASC")
+		}
+		err := query.Limit(1).Select()
+		if err == pg.ErrNoRows {
+			// Album is already at the top/bottom of the list
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Swap the positions
+		_, err = tx.Model(model).WherePK().Set("position = ?", neighbour.Position).Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(neighbour).WherePK().Set("position = ?", model.Position).Update()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to move the album (ID: %d). Err: %s", model.AlbumID, err.Error())
+		return fmt.Errorf("Unable to reorder the albums at the moment")
+	}
+
+	return nil
+}
+
+// SetCover sets an item of the album as its cover
+func (model *Album) SetCover(log logger.MultiLogger, itemID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	albumItem := &AlbumItem{
+		AlbumID: model.AlbumID,
+		ItemID:  itemID,
+	}
+	err = client.GetPGClient().Select(albumItem)
+	if err != nil {
+		log.Errorf("Unable to get the item - %d in album - %d. Err: %s", itemID, model.AlbumID, err.Error())
+		return fmt.Errorf("The item is not a part of the album")
+	}
+
+	model.CoverItemID = itemID
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("cover_item_id = ?", itemID).
+		Set("last_updated = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to set the cover of the album (ID: %d). Err: %s", model.AlbumID, err.Error())
+		return fmt.Errorf("Unable to set the album cover at the moment")
+	}
+
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to set the album cover at the moment")
+	}
+
+	return nil
+}
+
+// AddItem adds an item of the album's group at the end of the album
+// The items pending for approval are not added, as the albums can be shared through public links
+func (model *Album) AddItem(log logger.MultiLogger, item *Item, userID int64) error {
+	if item.GroupID != model.GroupID {
+		return fmt.Errorf("Only the items of the group can be added to the album")
+	}
+	if item.IsPending() {
+		return fmt.Errorf("The item is pending for approval and cannot be added to the album")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	albumItem := &AlbumItem{
+		AlbumID: model.AlbumID,
+		ItemID:  item.ItemID,
+		AddedBy: userID,
+	}
+
+	res, err := client.GetPGClient().Model(albumItem).
+		Value("position", "(SELECT coalesce(max(position), 0) + 1 FROM albumitems WHERE album_id = ?)", model.AlbumID).
+		OnConflict("DO NOTHING").
+		Insert()
+	if err != nil {
+		log.Errorf("Unable to add item - %d to album - %d. Err: %s", item.ItemID, model.AlbumID, err.Error())
+		return fmt.Errorf("Unable to add the item to the album at the moment")
+	}
+
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The item is already a part of the album")
+	}
+
+	return nil
+}
+
+// RemoveItem removes an item from the album
+// The album cover is cleared if the item was used as the cover
+func (model *Album) RemoveItem(log logger.MultiLogger, itemID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		albumItem := &AlbumItem{
+			AlbumID: model.AlbumID,
+			ItemID:  itemID,
+		}
+		res, err := tx.Model(albumItem).WherePK().Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return pg.ErrNoRows
+		}
+
+		_, err = tx.Model(model).WherePK().
+			Where("cover_item_id = ?", itemID).
+			Set("cover_item_id = NULL").
+			Update()
+		return err
+	})
+	if err == pg.ErrNoRows {
+		return fmt.Errorf("The item is not a part of the album")
+	}
+	if err != nil {
+		log.Errorf("Unable to remove item - %d from album - %d. Err: %s", itemID, model.AlbumID, err.Error())
+		return fmt.Errorf("Unable to remove the item from the album at the moment")
+	}
+
+	return nil
+}
+
+// MoveItem moves an item one place up or down within the album
+func (model *Album) MoveItem(log logger.MultiLogger, itemID int64, up bool) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		albumItem := &AlbumItem{
+			AlbumID: model.AlbumID,
+			ItemID:  itemID,
+		}
+		err := tx.Select(albumItem)
+		if err != nil {
+			return err
+		}
+
+		// Find the neighbouring item
+		neighbour := &AlbumItem{}
+		query := tx.Model(neighbour).Where("album_id = ?", model.AlbumID)
+		if up {
+			query = query.Where("position < ?", albumItem.Position).Order("position DESC")
+		} else {
+			query = query.Where("position > ?", albumItem.Position).Order("position ASC")
+		}
+		err = query.Limit(1).Select()
+		if err == pg.ErrNoRows {
+			// Item is already at the start/end of the album
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Swap the positions
+		_, err = tx.Model(albumItem).WherePK().Set("position = ?", neighbour.Position).Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(neighbour).WherePK().Set("position = ?", albumItem.Position).Update()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to move item - %d in album - %d. Err: %s", itemID, model.AlbumID, err.Error())
+		return fmt.Errorf("Unable to reorder the items at the moment")
+	}
+
+	return nil
+}
+
+// GetAlbumNavigation returns the previous and next items of an item within the album
+// nil is returned if the item is not a part of the album
+func (model *Album) GetAlbumNavigation(log logger.MultiLogger, itemID int64) (*AlbumNavigation, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	navigation := &AlbumNavigation{
+		AlbumID:   model.AlbumID,
+		AlbumName: model.AlbumName,
+	}
+
+	_, err = client.GetPGClient().QueryOne(navigation, `
+		SELECT t.prev_item_id, t.next_item_id, t.item_position, t.item_count FROM (
+			SELECT ai.item_id,
+				lag(ai.item_id) OVER w AS prev_item_id,
+				lead(ai.item_id) OVER w AS next_item_id,
+				row_number() OVER w AS item_position,
+				count(*) OVER () AS item_count
+			FROM albumitems AS ai
+			JOIN items AS i ON i.item_id = ai.item_id AND i.uploaded = true AND i.approval_status = ?
+			WHERE ai.album_id = ?
+			WINDOW w AS (ORDER BY ai.position, ai.item_id)
+		) AS t WHERE t.item_id = ?`, common.ItemApprovalPublished.GetStatusID(), model.AlbumID, itemID)
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Errorf("Unable to get the navigation for item - %d in album - %d. Err: %s", itemID, model.AlbumID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the album details")
+	}
+
+	return navigation, nil
+}
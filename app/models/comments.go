@@ -4,34 +4,100 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/database"
 )
 
 // Comment is the model for comments database table
 type Comment struct {
-	tableName    struct{}  `sql:"Comments,alias:c"`
-	CommentID    int64     `sql:"comment_id,pk"`
-	Comment      string    `sql:"comment"`
-	ItemID       int64     `sql:"item_id"`
-	CreatedBy    int64     `sql:"created_by"`
-	CreationTime time.Time `sql:"creation_time"`
+	tableName       struct{}  `sql:"Comments,alias:c"`
+	CommentID       int64     `sql:"comment_id,pk"`
+	Comment         string    `sql:"comment"`
+	ItemID          int64     `sql:"item_id"`
+	ParentCommentID int64     `sql:"parent_comment_id"`
+	IsDeleted       bool      `sql:"is_deleted,default:false"`
+	CreatedBy       int64     `sql:"created_by"`
+	CreationTime    time.Time `sql:"creation_time"`
+	LastUpdated     time.Time `sql:"last_updated"`
+	GroupID         int64     `sql:"group_id"`
 }
 
 // CommentDisplay is the model for comments for frontend
+// Replies holds the replies to the comment, CanReply, CanEdit and CanDelete are set for the logged in user
 type CommentDisplay struct {
-	tableName          struct{}  `sql:"Comments,alias:c"`
-	CommentID          int64     `sql:"comment_id,pk"`
-	Comment            string    `sql:"comment"`
-	ItemID             int64     `sql:"item_id"`
-	CreatedBy          int64     `sql:"created_by"`
-	CreatedByFirstName string    `sql:"created_by_first_name"`
-	CreatedByLastName  string    `sql:"created_by_last_name"`
-	CreationTime       time.Time `sql:"creation_time"`
+	tableName          struct{}          `sql:"Comments,alias:c"`
+	CommentID          int64             `sql:"comment_id,pk"`
+	Comment            string            `sql:"comment"`
+	ItemID             int64             `sql:"item_id"`
+	ParentCommentID    int64             `sql:"parent_comment_id"`
+	IsDeleted          bool              `sql:"is_deleted"`
+	CreatedBy          int64             `sql:"created_by"`
+	CreatedByFirstName string            `sql:"created_by_first_name"`
+	CreatedByLastName  string            `sql:"created_by_last_name"`
+	CreationTime       time.Time         `sql:"creation_time"`
+	LastUpdated        time.Time         `sql:"last_updated"`
+	GroupID            int64             `sql:"group_id"`
+	Replies            []*CommentDisplay `sql:"-"`
+	CanReply           bool              `sql:"-"`
+	CanEdit            bool              `sql:"-"`
+	CanDelete          bool              `sql:"-"`
+	Mentions           map[string]string `sql:"-"`
+	Reactions          *ReactionBar      `sql:"-"`
 }
 
-// GetCommentsForAnItem returns all the comments for an item
-func GetCommentsForAnItem(log logger.MultiLogger, itemID int64) ([]*CommentDisplay, error) {
+// IsEdited returns whether the comment was edited after it was posted
+func (model *CommentDisplay) IsEdited() bool {
+	return !model.IsDeleted && !model.LastUpdated.IsZero()
+}
+
+// SetPermissions sets CanReply, CanEdit and CanDelete on the comment thread for the given user
+// canComment is whether the role of the user in the group allows commenting
+// The author can edit and delete a comment, moderators (and admins) can delete any comment
+func SetPermissions(comments []*CommentDisplay, userID int64, canComment, isModerator bool) {
+	for _, comment := range comments {
+		if !comment.IsDeleted {
+			comment.CanReply = canComment
+			comment.CanEdit = comment.CreatedBy == userID && canComment
+			comment.CanDelete = comment.CreatedBy == userID || isModerator
+		}
+		SetPermissions(comment.Replies, userID, canComment, isModerator)
+	}
+}
+
+// getCommentTexts returns the texts of all the comments in the threads, keyed by the group the comment was posted in
+// Comments without a group were posted in the primary group of the item
+func getCommentTexts(comments []*CommentDisplay, primaryGroupID int64, texts map[int64][]string) {
+	for _, comment := range comments {
+		if !comment.IsDeleted {
+			groupID := comment.GroupID
+			if groupID == 0 {
+				groupID = primaryGroupID
+			}
+			texts[groupID] = append(texts[groupID], comment.Comment)
+		}
+		getCommentTexts(comment.Replies, primaryGroupID, texts)
+	}
+}
+
+// setCommentMentions sets the links of the users mentioned on all the comments in the threads,
+// the mentions are resolved in the group the comment was posted in
+func setCommentMentions(comments []*CommentDisplay, primaryGroupID int64, mentions map[int64]map[string]string) {
+	for _, comment := range comments {
+		groupID := comment.GroupID
+		if groupID == 0 {
+			groupID = primaryGroupID
+		}
+		comment.Mentions = mentions[groupID]
+		setCommentMentions(comment.Replies, primaryGroupID, mentions)
+	}
+}
+
+// GetCommentsForAnItem returns all the comments for an item as threads
+// Top level comments are returned in time order with the replies nested under their parent comment
+// groupID limits the comments to the ones posted in the group (comments without a group were posted in the primary group), 0 for all
+func GetCommentsForAnItem(log logger.MultiLogger, itemID, groupID int64) ([]*CommentDisplay, error) {
 	// Get Database client
 	client, err := database.GetClient()
 	if err != nil {
@@ -42,12 +108,16 @@ func GetCommentsForAnItem(log logger.MultiLogger, itemID int64) ([]*CommentDispl
 	var comments []*CommentDisplay
 
 	query := client.GetPGClient().Model(&comments)
-	query = query.ColumnExpr(`"c".comment, "c".creation_time`).
+	query = query.ColumnExpr(`"c".comment_id, "c".comment, "c".item_id, "c".parent_comment_id, "c".is_deleted`).
+		ColumnExpr(`"c".created_by, "c".creation_time, "c".last_updated, "c".group_id`).
 		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
 		Join("JOIN appuser AS u").
 		JoinOn("u.user_id = \"c\".created_by").
-		Where("item_id = ?", itemID).
-		Order("creation_time ASC")
+		Where("\"c\".item_id = ?", itemID).
+		OrderExpr(`"c".creation_time ASC, "c".comment_id ASC`)
+	if groupID > 0 {
+		query = query.Where(`coalesce("c".group_id, (SELECT i.group_id FROM items AS i WHERE i.item_id = "c".item_id)) = ?`, groupID)
+	}
 
 	err = query.Select()
 	if err != nil {
@@ -55,10 +125,45 @@ func GetCommentsForAnItem(log logger.MultiLogger, itemID int64) ([]*CommentDispl
 		return nil, fmt.Errorf("Unable to process the request")
 	}
 
-	return comments, nil
+	// Build the threads, parents are always created before their replies
+	var threads []*CommentDisplay
+	commentMap := make(map[int64]*CommentDisplay)
+	for _, comment := range comments {
+		commentMap[comment.CommentID] = comment
+
+		parent, present := commentMap[comment.ParentCommentID]
+		if comment.ParentCommentID == 0 || !present {
+			threads = append(threads, comment)
+			continue
+		}
+		parent.Replies = append(parent.Replies, comment)
+	}
+
+	return threads, nil
+}
+
+// GetCommentByID returns the comment with the given ID
+func GetCommentByID(log logger.MultiLogger, commentID int64) (*Comment, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	comment := &Comment{
+		CommentID: commentID,
+	}
+	err = client.GetPGClient().Select(comment)
+	if err != nil {
+		log.Errorf("Unable to get the comment - %d. Err: %s", commentID, err.Error())
+		return nil, fmt.Errorf("The comment does not exist")
+	}
+
+	return comment, nil
 }
 
-// Add adds the comment to the database
+// Add adds the comment to the database and updates the comment count of the item
 func (model *Comment) Add(log logger.MultiLogger) error {
 	// Get Database client
 	client, err := database.GetClient()
@@ -67,15 +172,130 @@ func (model *Comment) Add(log logger.MultiLogger) error {
 		return fmt.Errorf("Unable to process the request")
 	}
 
-	res, err := client.GetPGClient().Model(model).OnConflict("DO NOTHING").Insert()
+	// Replies are allowed only to the existing comments of the same item
+	if model.ParentCommentID > 0 {
+		parent := &Comment{
+			CommentID: model.ParentCommentID,
+		}
+		err = client.GetPGClient().Select(parent)
+		if err != nil {
+			log.Errorf("Unable to get the parent comment - %d. Err: %s", model.ParentCommentID, err.Error())
+			return fmt.Errorf("The comment being replied to does not exist")
+		}
+		if parent.ItemID != model.ItemID || parent.IsDeleted {
+			return fmt.Errorf("The comment being replied to does not exist")
+		}
+	}
+
+	inserted := true
+	item := &Item{}
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(model).OnConflict("DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			inserted = false
+			return nil
+		}
+
+		_, err = tx.Model(item).
+			Set("comment_count = comment_count + 1").
+			Where("item_id = ?", model.ItemID).
+			Returning("*").
+			Update()
+		return err
+	})
 	if err != nil {
 		log.Errorf("Unable to insert the comment into database. Err: %s", err.Error())
 		return fmt.Errorf("Unable to process the request")
 	}
 
+	if !inserted {
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	// The comment is logged in the group it was posted in
+	groupID := item.GroupID
+	if model.GroupID > 0 {
+		groupID = model.GroupID
+	}
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   groupID,
+		ActorID:   model.CreatedBy,
+		EventType: common.GroupEventCommentAdded.GetTypeID(),
+		ItemID:    item.ItemID,
+		ItemName:  item.ItemName,
+	})
+
+	return nil
+}
+
+// Update updates the text of the comment and marks it as edited
+func (model *Comment) Update(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("comment = ?comment").
+		Set("last_updated = now()").
+		Where("is_deleted = ?", false).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the comment - %d. Err: %s", model.CommentID, err.Error())
+		return fmt.Errorf("Unable to update the comment at the moment")
+	}
+
 	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to update the comment at the moment")
+	}
+
+	return nil
+}
+
+// Delete marks the comment as deleted and updates the comment count of the item
+// The comment is retained so that the replies to it stay in their thread
+func (model *Comment) Delete(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
 		return fmt.Errorf("Unable to process the request")
 	}
 
+	deleted := true
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(model).WherePK().
+			Set("is_deleted = ?", true).
+			Set("last_updated = now()").
+			Where("is_deleted = ?", false).
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			deleted = false
+			return nil
+		}
+
+		_, err = tx.Model((*Item)(nil)).
+			Set("comment_count = comment_count - 1").
+			Where("item_id = ?", model.ItemID).
+			Update()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to delete the comment - %d. Err: %s", model.CommentID, err.Error())
+		return fmt.Errorf("Unable to delete the comment at the moment")
+	}
+
+	if !deleted {
+		return fmt.Errorf("The comment is already deleted")
+	}
+
 	return nil
 }
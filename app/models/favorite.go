@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// Favorite is the model for the items starred by the users
+type Favorite struct {
+	tableName    struct{}  `sql:"Favorites,alias:f"`
+	UserID       int64     `sql:"user_id,pk"`
+	ItemID       int64     `sql:"item_id,pk"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// FavoriteItem is the model for an item listed on the favorites page
+type FavoriteItem struct {
+	tableName   struct{}  `sql:"Favorites,alias:f"`
+	ItemID      int64     `sql:"item_id"`
+	ItemName    string    `sql:"item_name"`
+	Description string    `sql:"description"`
+	ItemTypeID  int       `sql:"item_type_id"`
+	ItemPath    string    `sql:"item_path"`
+	GroupID     int64     `sql:"group_id"`
+	GroupName   string    `sql:"group_name"`
+	StarredOn   time.Time `sql:"creation_time"`
+}
+
+// ToggleFavorite stars the item for the user, or removes the star if the item is already starred
+func (model *Favorite) ToggleFavorite(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().Delete()
+	if err != nil {
+		log.Errorf("Unable to remove the favorite item - %d of user - %d. Err: %s", model.ItemID, model.UserID, err.Error())
+		return fmt.Errorf("Unable to update the favorites at the moment")
+	}
+	if res.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = client.GetPGClient().Model(model).OnConflict("DO NOTHING").Insert()
+	if err != nil {
+		log.Errorf("Unable to add the favorite item - %d of user - %d. Err: %s", model.ItemID, model.UserID, err.Error())
+		return fmt.Errorf("Unable to update the favorites at the moment")
+	}
+
+	return nil
+}
+
+// IsFavorite checks whether the user has starred the item
+func IsFavorite(log logger.MultiLogger, userID, itemID int64) (bool, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return false, fmt.Errorf("Unable to process the request")
+	}
+
+	count, err := client.GetPGClient().Model((*Favorite)(nil)).
+		Where("user_id = ?", userID).
+		Where("item_id = ?", itemID).
+		Count()
+	if err != nil {
+		log.Errorf("Unable to check the favorite item - %d of user - %d. Err: %s", itemID, userID, err.Error())
+		return false, fmt.Errorf("Unable to fetch the favorites")
+	}
+
+	return count > 0, nil
+}
+
+// GetFavoritesForUser returns the items starred by the user, latest first
+// Items of the groups the user no longer has access to are left out, the favorites are kept as they are
+func GetFavoritesForUser(log logger.MultiLogger, userID int64) ([]*FavoriteItem, error) {
+	// Get all the groups visible to the user
+	groups, err := GetAllGroupsKeyVal(userID)
+	if err != nil {
+		log.Errorf("Unable to get the groups for user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the favorites")
+	}
+
+	groupIDs := make([]int64, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var favorites []*FavoriteItem
+	err = client.GetPGClient().Model(&favorites).
+		ColumnExpr(`"f".item_id, "f".creation_time, "item".item_name, "item".description, "item".item_type_id`).
+		ColumnExpr(`"item".item_path, g.group_id, g.group_name`).
+		Join("JOIN items AS \"item\"").
+		JoinOn("\"item\".item_id = \"f\".item_id").
+		// Items are listed through their primary group or a group they are shared into
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = "+itemAccessGroupExpr, pg.Ints(groupIDs)).
+		Where("\"f\".user_id = ?", userID).
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		OrderExpr(`"f".creation_time DESC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the favorites of user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the favorites")
+	}
+
+	return favorites, nil
+}
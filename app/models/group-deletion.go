@@ -0,0 +1,333 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// GroupDeletion is the model for the progress of the background deletion of a group
+// GroupName is a copy of the group's name, so that the progress can be shown after the group is removed
+type GroupDeletion struct {
+	tableName    struct{}  `sql:"GroupDeletions,alias:gd"`
+	DeletionID   int64     `sql:"deletion_id,pk"`
+	GroupID      int64     `sql:"group_id"`
+	GroupName    string    `sql:"group_name"`
+	RequestedBy  int64     `sql:"requested_by"`
+	Status       int       `sql:"status"`
+	TotalItems   int       `sql:"total_items"`
+	DeletedItems int       `sql:"deleted_items"`
+	FailedFiles  int       `sql:"failed_files"`
+	Error        string    `sql:"error"`
+	CreationTime time.Time `sql:"creation_time"`
+	FinishedAt   time.Time `sql:"finished_at"`
+}
+
+// GroupDeletionView is the display model for the progress of the group deletions
+type GroupDeletionView struct {
+	tableName            struct{}  `sql:"GroupDeletions,alias:gd"`
+	DeletionID           int64     `sql:"deletion_id,pk"`
+	GroupID              int64     `sql:"group_id"`
+	GroupName            string    `sql:"group_name"`
+	RequestedByFirstName string    `sql:"requested_by_first_name"`
+	RequestedByLastName  string    `sql:"requested_by_last_name"`
+	Status               int       `sql:"status"`
+	TotalItems           int       `sql:"total_items"`
+	DeletedItems         int       `sql:"deleted_items"`
+	FailedFiles          int       `sql:"failed_files"`
+	Error                string    `sql:"error"`
+	CreationTime         time.Time `sql:"creation_time"`
+	FinishedAt           time.Time `sql:"finished_at"`
+}
+
+// IsRunning returns whether the deletion is still in progress
+func (model *GroupDeletionView) IsRunning() bool {
+	return model.Status == common.GroupDeletionRunning.GetStatusID()
+}
+
+// GetStatus returns the text of the status of the deletion
+func (model *GroupDeletionView) GetStatus() string {
+	return common.GroupDeletionStatus(model.Status).GetString()
+}
+
+// GetProgress returns the percentage of the items of the group deleted so far
+func (model *GroupDeletionView) GetProgress() int {
+	if model.Status == common.GroupDeletionCompleted.GetStatusID() {
+		return 100
+	}
+	if model.TotalItems == 0 {
+		return 0
+	}
+	return model.DeletedItems * 100 / model.TotalItems
+}
+
+// StartGroupDeletion archives the group and starts deleting it along with all its content in the background
+// The returned deletion is used for following the progress
+func StartGroupDeletion(log logger.MultiLogger, groupID, adminID int64) (*GroupDeletion, error) {
+	// Note: Authz check is already done at this point
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	group := &Group{
+		GroupID: groupID,
+	}
+	err = client.GetPGClient().Select(group)
+	if err != nil {
+		log.Errorf("Unable to get the group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("The group does not exist")
+	}
+
+	deletion := &GroupDeletion{
+		GroupID:     groupID,
+		GroupName:   group.GroupName,
+		RequestedBy: adminID,
+		Status:      common.GroupDeletionRunning.GetStatusID(),
+	}
+	res, err := client.GetPGClient().Model(deletion).OnConflict("DO NOTHING").Returning("*").Insert()
+	if err != nil {
+		log.Errorf("Unable to insert the group deletion into database. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to delete the group at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return nil, fmt.Errorf("The group '%s' is already being deleted", group.GroupName)
+	}
+
+	// No items or comments are added while the content is cleaned up
+	_, err = client.GetPGClient().Model(group).WherePK().
+		Set("is_archived = ?", true).
+		Set("last_updated = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to archive the group - %d. Err: %s", groupID, err.Error())
+		deletion.finish(log, fmt.Errorf("Unable to archive the group"))
+		return nil, fmt.Errorf("Unable to delete the group at the moment")
+	}
+
+	go deletion.run(log)
+
+	return deletion, nil
+}
+
+// run deletes the items of the group in batches, updating the progress after every batch,
+// and then removes the group along with its members, tags, albums and the comments posted in it
+func (model *GroupDeletion) run(log logger.MultiLogger) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		model.finish(log, fmt.Errorf("Unable to connect to the database"))
+		return
+	}
+	db := client.GetPGClient()
+
+	model.TotalItems, err = db.Model((*Item)(nil)).Where("group_id = ?", model.GroupID).Count()
+	if err != nil {
+		log.Errorf("Unable to count the items of group - %d. Err: %s", model.GroupID, err.Error())
+		model.finish(log, fmt.Errorf("Unable to count the items of the group"))
+		return
+	}
+	model.updateProgress(log)
+
+	for {
+		var items []*Item
+		err = db.Model(&items).
+			Where("group_id = ?", model.GroupID).
+			Order("item_id ASC").
+			Limit(common.GroupDeletionBatchSize).
+			Select()
+		if err != nil {
+			log.Errorf("Unable to get the items of group - %d. Err: %s", model.GroupID, err.Error())
+			model.finish(log, fmt.Errorf("Unable to get the items of the group"))
+			return
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		itemIDs := make([]int64, 0, len(items))
+		for _, item := range items {
+			itemIDs = append(itemIDs, item.ItemID)
+		}
+
+		// The comments and the rest of the details of the items cascade
+		_, err = db.Model((*Item)(nil)).Where("item_id in (?)", pg.Ints(itemIDs)).Delete()
+		if err != nil {
+			log.Errorf("Unable to delete the items of group - %d. Err: %s", model.GroupID, err.Error())
+			model.finish(log, fmt.Errorf("Unable to delete the items of the group"))
+			return
+		}
+
+		// The rows are gone, a file that cannot be removed is only counted
+		for _, item := range items {
+			err = os.Remove(fmt.Sprintf("./%s", item.ItemPath))
+			if err != nil && !os.IsNotExist(err) {
+				log.Errorf("Unable to remove the file of item - %d. Err: %s", item.ItemID, err.Error())
+				model.FailedFiles++
+			}
+		}
+
+		model.DeletedItems += len(items)
+		model.updateProgress(log)
+	}
+
+	err = db.RunInTransaction(func(tx *pg.Tx) error {
+		// Comments posted in the group on the items shared into it, their replies from other groups are kept
+		// as top level comments (the parent comment is cleared by the database)
+		var sharedItemIDs []int64
+		err := tx.Model((*Comment)(nil)).
+			ColumnExpr("DISTINCT item_id").
+			Where("group_id = ?", model.GroupID).
+			Select(&sharedItemIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model((*Comment)(nil)).
+			Where("group_id = ?", model.GroupID).
+			Delete()
+		if err != nil {
+			return err
+		}
+
+		if len(sharedItemIDs) > 0 {
+			_, err = tx.Model((*Item)(nil)).
+				Set(`comment_count = (SELECT count(*) FROM comments AS c WHERE c.item_id = "item".item_id AND NOT c.is_deleted)`).
+				Where(`"item".item_id in (?)`, pg.Ints(sharedItemIDs)).
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Model((*Tag)(nil)).Where("group_id = ?", model.GroupID).Delete()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model((*Album)(nil)).Where("group_id = ?", model.GroupID).Delete()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model((*UserGroupMap)(nil)).Where("group_id = ?", model.GroupID).Delete()
+		if err != nil {
+			return err
+		}
+
+		// The settings, shares, share links, invitations, join requests, events and notifications of the group cascade
+		// and its subgroups are moved to the top level
+		_, err = tx.Model((*Group)(nil)).Where("group_id = ?", model.GroupID).Delete()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to delete group - %d. Err: %s", model.GroupID, err.Error())
+		model.finish(log, fmt.Errorf("Unable to delete the members, tags and albums of the group"))
+		return
+	}
+
+	model.finish(log, nil)
+}
+
+// updateProgress stores the counts of the deletion, the failure is only logged
+func (model *GroupDeletion) updateProgress(log logger.MultiLogger) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return
+	}
+
+	_, err = client.GetPGClient().Model(model).WherePK().
+		Set("total_items = ?", model.TotalItems).
+		Set("deleted_items = ?", model.DeletedItems).
+		Set("failed_files = ?", model.FailedFiles).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the progress of group deletion - %d. Err: %s", model.DeletionID, err.Error())
+	}
+}
+
+// finish marks the deletion as completed, or as failed with the given error
+func (model *GroupDeletion) finish(log logger.MultiLogger, deletionErr error) {
+	model.Status = common.GroupDeletionCompleted.GetStatusID()
+	if deletionErr != nil {
+		model.Status = common.GroupDeletionFailed.GetStatusID()
+		model.Error = deletionErr.Error()
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return
+	}
+
+	_, err = client.GetPGClient().Model(model).WherePK().
+		Set("status = ?", model.Status).
+		Set("error = ?", model.Error).
+		Set("deleted_items = ?", model.DeletedItems).
+		Set("failed_files = ?", model.FailedFiles).
+		Set("finished_at = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the status of group deletion - %d. Err: %s", model.DeletionID, err.Error())
+	}
+}
+
+// GetGroupDeletionByID returns the progress of the group deletion with the given ID
+func GetGroupDeletionByID(log logger.MultiLogger, deletionID int64) (*GroupDeletionView, error) {
+	deletions, err := getGroupDeletions(log, deletionID)
+	if err != nil {
+		// error is already logged
+		return nil, err
+	}
+	if len(deletions) == 0 {
+		return nil, fmt.Errorf("The group deletion does not exist")
+	}
+
+	return deletions[0], nil
+}
+
+// GetGroupDeletions returns all the group deletions, the latest first
+func GetGroupDeletions(log logger.MultiLogger) ([]*GroupDeletionView, error) {
+	return getGroupDeletions(log, 0)
+}
+
+// getGroupDeletions returns the group deletion with the given ID, or all of them for the ID 0
+func getGroupDeletions(log logger.MultiLogger, deletionID int64) ([]*GroupDeletionView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var deletions []*GroupDeletionView
+	query := client.GetPGClient().Model(&deletions).
+		ColumnExpr(`gd.deletion_id, gd.group_id, gd.group_name, gd.status, gd.total_items, gd.deleted_items`).
+		ColumnExpr(`gd.failed_files, gd.error, gd.creation_time, gd.finished_at`).
+		ColumnExpr(`u.first_name AS requested_by_first_name, u.last_name AS requested_by_last_name`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = gd.requested_by").
+		OrderExpr("gd.deletion_id DESC")
+	if deletionID > 0 {
+		query = query.Where("gd.deletion_id = ?", deletionID)
+	}
+	err = query.Select()
+	if err != nil {
+		log.Errorf("Unable to get the group deletions. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to fetch the group deletions")
+	}
+
+	return deletions, nil
+}
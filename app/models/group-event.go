@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// GroupEvent is the model for the events in the activity feed of a group
+// ItemName is a copy of the item's name at the time of the event, so that events of deleted items can be shown
+type GroupEvent struct {
+	tableName    struct{}  `sql:"GroupEvents,alias:ge"`
+	EventID      int64     `sql:"event_id,pk"`
+	GroupID      int64     `sql:"group_id"`
+	ActorID      int64     `sql:"actor_id"`
+	EventType    int       `sql:"event_type"`
+	ItemID       int64     `sql:"item_id"`
+	ItemName     string    `sql:"item_name"`
+	TargetUserID int64     `sql:"target_user_id"`
+	Details      string    `sql:"details"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// GroupEventView is the display model for the events in the activity feed
+type GroupEventView struct {
+	tableName       struct{}  `sql:"GroupEvents,alias:ge"`
+	EventID         int64     `sql:"event_id,pk"`
+	GroupID         int64     `sql:"group_id"`
+	GroupName       string    `sql:"group_name"`
+	EventType       int       `sql:"event_type"`
+	ItemID          int64     `sql:"item_id"`
+	ItemName        string    `sql:"item_name"`
+	ItemExists      bool      `sql:"item_exists"`
+	Details         string    `sql:"details"`
+	ActorFirstName  string    `sql:"actor_first_name"`
+	ActorLastName   string    `sql:"actor_last_name"`
+	TargetFirstName string    `sql:"target_first_name"`
+	TargetLastName  string    `sql:"target_last_name"`
+	CreationTime    time.Time `sql:"creation_time"`
+}
+
+// GroupActivity is a page of the activity feed of a group (GroupID is 0 for the activity across all the groups)
+// NextCursor is the event ID to continue from, 0 when there are no more events
+type GroupActivity struct {
+	GroupID    int64
+	GroupName  string
+	Events     []*GroupEventView
+	NextCursor int64
+}
+
+// GetMessage returns the text of the event, the item (if any) is linked separately by the view
+func (model *GroupEventView) GetMessage() string {
+	actor := fmt.Sprintf("%s %s", model.ActorFirstName, model.ActorLastName)
+	target := fmt.Sprintf("%s %s", model.TargetFirstName, model.TargetLastName)
+	eventType := common.GroupEventType(model.EventType)
+
+	switch eventType {
+	case common.GroupEventMemberAdded:
+		return fmt.Sprintf("%s %s %s to the group", actor, eventType.GetString(), target)
+	case common.GroupEventLeaderPromoted:
+		return fmt.Sprintf("%s %s %s to a leader of the group", actor, eventType.GetString(), target)
+	case common.GroupEventLimitsChanged:
+		return fmt.Sprintf("%s %s the group (%s)", actor, eventType.GetString(), model.Details)
+	case common.GroupEventMemberRemoved:
+		return fmt.Sprintf("%s %s %s from the group (%s)", actor, eventType.GetString(), target, model.Details)
+	case common.GroupEventMemberLeft:
+		return fmt.Sprintf("%s %s the group (%s)", actor, eventType.GetString(), model.Details)
+	case common.GroupEventRoleChanged:
+		return fmt.Sprintf("%s %s %s to %s", actor, eventType.GetString(), target, model.Details)
+	case common.GroupEventRenamed:
+		return fmt.Sprintf("%s %s the group (%s)", actor, eventType.GetString(), model.Details)
+	case common.GroupEventArchived, common.GroupEventRestored:
+		return fmt.Sprintf("%s %s the group", actor, eventType.GetString())
+	case common.GroupEventLeaderDemoted:
+		return fmt.Sprintf("%s %s %s from a leader to %s", actor, eventType.GetString(), target, model.Details)
+	case common.GroupEventVisibilityChanged, common.GroupEventParentChanged:
+		return fmt.Sprintf("%s %s %s", actor, eventType.GetString(), model.Details)
+	case common.GroupEventOwnershipTransferred:
+		return fmt.Sprintf("%s %s %s", actor, eventType.GetString(), target)
+	case common.GroupEventMemberJoined:
+		return fmt.Sprintf("%s %s the group, invited by %s", actor, eventType.GetString(), target)
+	}
+
+	return fmt.Sprintf("%s %s", actor, eventType.GetString())
+}
+
+// logGroupEvent adds the event to the activity feed of the group
+// The action the event describes has already taken place, so failures are only logged
+func logGroupEvent(log logger.MultiLogger, event *GroupEvent) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return
+	}
+
+	_, err = client.GetPGClient().Model(event).Insert()
+	if err != nil {
+		log.Errorf("Unable to log the event (type: %d) of group - %d. Err: %s", event.EventType, event.GroupID, err.Error())
+	}
+}
+
+// GetGroupActivity returns a page of the events of the given groups, latest first
+// before is the NextCursor of the previous page, 0 for the first page
+func GetGroupActivity(log logger.MultiLogger, groupIDs []int64, before int64) (*GroupActivity, error) {
+	activity := &GroupActivity{}
+	if groupIDs != nil && len(groupIDs) == 0 {
+		return activity, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var events []*GroupEventView
+	query := client.GetPGClient().Model(&events).
+		ColumnExpr(`"ge".event_id, "ge".group_id, "ge".event_type, "ge".item_id, "ge".item_name, "ge".details`).
+		ColumnExpr(`"ge".creation_time, g.group_name, i.item_id IS NOT NULL AS item_exists`).
+		ColumnExpr(`a.first_name AS actor_first_name, a.last_name AS actor_last_name`).
+		ColumnExpr(`t.first_name AS target_first_name, t.last_name AS target_last_name`).
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = \"ge\".group_id").
+		Join("JOIN appuser AS a").
+		JoinOn("a.user_id = \"ge\".actor_id").
+		Join("LEFT JOIN appuser AS t").
+		JoinOn("t.user_id = \"ge\".target_user_id").
+		Join("LEFT JOIN items AS i").
+		JoinOn("i.item_id = \"ge\".item_id")
+
+	// nil groupIDs lists the events of all the groups
+	if groupIDs != nil {
+		query = query.Where("\"ge\".group_id in (?)", pg.Ints(groupIDs))
+	}
+	if before > 0 {
+		query = query.Where("\"ge\".event_id < ?", before)
+	}
+
+	// Fetch one extra event to find out whether there is a next page
+	err = query.OrderExpr(`"ge".event_id DESC`).
+		Limit(common.ActivityPageSize + 1).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the group events. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to fetch the activity")
+	}
+
+	if len(events) > common.ActivityPageSize {
+		events = events[:common.ActivityPageSize]
+		activity.NextCursor = events[len(events)-1].EventID
+	}
+	activity.Events = events
+
+	return activity, nil
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// GroupTreeNode is a group of the group list along with its depth in the tree of the groups
+type GroupTreeNode struct {
+	*GroupView
+	Depth int
+}
+
+// GetIndent returns the indentation of the group in the group list (in rem)
+func (model *GroupTreeNode) GetIndent() int {
+	return model.Depth * 2
+}
+
+// BuildGroupTree orders the groups depth first, so that the subgroups follow their parent group
+// The groups whose parent is not in the list are listed at the top level
+func BuildGroupTree(groups []*GroupView) []*GroupTreeNode {
+	present := make(map[int64]bool)
+	for _, group := range groups {
+		present[group.GroupID] = true
+	}
+
+	var roots []*GroupView
+	subgroups := make(map[int64][]*GroupView)
+	for _, group := range groups {
+		if group.ParentGroupID > 0 && present[group.ParentGroupID] {
+			subgroups[group.ParentGroupID] = append(subgroups[group.ParentGroupID], group)
+		} else {
+			roots = append(roots, group)
+		}
+	}
+
+	tree := make([]*GroupTreeNode, 0, len(groups))
+	added := make(map[int64]bool)
+	var addGroup func(group *GroupView, depth int)
+	addGroup = func(group *GroupView, depth int) {
+		if added[group.GroupID] {
+			return
+		}
+		added[group.GroupID] = true
+		tree = append(tree, &GroupTreeNode{GroupView: group, Depth: depth})
+		for _, subgroup := range subgroups[group.GroupID] {
+			addGroup(subgroup, depth+1)
+		}
+	}
+	for _, group := range roots {
+		addGroup(group, 0)
+	}
+
+	return tree
+}
+
+// GetSubgroups returns the subgroups of the group from the given groups of the user
+func GetSubgroups(groups []*GroupKeyVal, groupID int64) []*GroupKeyVal {
+	var subgroups []*GroupKeyVal
+	for _, group := range groups {
+		if group.ParentGroupID == groupID {
+			subgroups = append(subgroups, group)
+		}
+	}
+
+	return subgroups
+}
+
+// GetParentCandidates returns the groups from the given groups that the group can be moved under,
+// the group itself and its subgroups are left out
+func GetParentCandidates(groups []*GroupKeyVal, groupID int64) []*GroupKeyVal {
+	parentGroupIDs := make(map[int64]int64)
+	for _, group := range groups {
+		parentGroupIDs[group.GroupID] = group.ParentGroupID
+	}
+
+	var candidates []*GroupKeyVal
+	for _, group := range groups {
+		inSubtree := false
+		visited := make(map[int64]bool)
+		for id := group.GroupID; id > 0 && !visited[id]; id = parentGroupIDs[id] {
+			visited[id] = true
+			if id == groupID {
+				inSubtree = true
+				break
+			}
+		}
+		if !inSubtree {
+			candidates = append(candidates, group)
+		}
+	}
+
+	return candidates
+}
+
+// getInheritedGroupIDs returns the approved groups the user can read through the membership of an ancestor group
+// A group inherits the members of its parent (as readers) when its inherit_members option is set, down the tree
+// The groups the user is a member of are not returned
+func getInheritedGroupIDs(userID int64) ([]int64, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get database client. Err: %s", err.Error())
+	}
+
+	// UNION (and not UNION ALL) stops the recursion on the groups already visited
+	var groupIDs []int64
+	_, err = client.GetPGClient().Query(&groupIDs, `
+		WITH RECURSIVE readable AS (
+			SELECT ugm.group_id FROM usergroupmap AS ugm WHERE ugm.user_id = ?0
+			UNION
+			SELECT g.group_id FROM groups AS g
+			JOIN readable AS r ON g.parent_group_id = r.group_id
+			WHERE g.inherit_members
+		)
+		SELECT g.group_id FROM readable AS r
+		JOIN groups AS g ON g.group_id = r.group_id AND g.workflow_status = ?1
+		WHERE NOT EXISTS (SELECT 1 FROM usergroupmap AS ugm WHERE ugm.group_id = r.group_id AND ugm.user_id = ?0)`,
+		userID, common.WorkflowStatusApproved.GetStatusID())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get the inherited groups for the user - %d. Error: %s", userID, err.Error())
+	}
+
+	return groupIDs, nil
+}
+
+// SetParent moves the group under the parent group, or to the top level if parentGroupID is 0
+// inheritMembers lets the members of the parent group read the group
+func (model *Group) SetParent(log logger.MultiLogger, parentGroupID int64, inheritMembers bool, actorID int64) error {
+	// Note: Authz check is already done at this point
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().Select(model)
+	if err != nil {
+		log.Errorf("Unable to get the group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("The group does not exist")
+	}
+
+	parentDetails := "to the top level"
+	if parentGroupID > 0 {
+		if parentGroupID == model.GroupID {
+			return fmt.Errorf("A group cannot be its own parent group")
+		}
+
+		parent := &Group{
+			GroupID: parentGroupID,
+		}
+		err = client.GetPGClient().Select(parent)
+		if err != nil || parent.WorkflowStatus != common.WorkflowStatusApproved.GetStatusID() {
+			return fmt.Errorf("The parent group does not exist")
+		}
+
+		// The parent group cannot be in the subtree of the group
+		var isSubgroup bool
+		_, err = client.GetPGClient().QueryOne(pg.Scan(&isSubgroup), `
+			WITH RECURSIVE ancestors AS (
+				SELECT g.group_id, g.parent_group_id FROM groups AS g WHERE g.group_id = ?0
+				UNION
+				SELECT g.group_id, g.parent_group_id FROM groups AS g
+				JOIN ancestors AS a ON g.group_id = a.parent_group_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors AS a WHERE a.group_id = ?1)`, parentGroupID, model.GroupID)
+		if err != nil {
+			log.Errorf("Unable to get the ancestors of group - %d. Err: %s", parentGroupID, err.Error())
+			return fmt.Errorf("Unable to process the request")
+		}
+		if isSubgroup {
+			return fmt.Errorf("The group '%s' is a subgroup of the group", parent.GroupName)
+		}
+		parentDetails = fmt.Sprintf("under '%s'", parent.GroupName)
+	}
+
+	previousParentGroupID := model.ParentGroupID
+	previousInheritMembers := model.InheritMembers
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("parent_group_id = NULLIF(?, 0)", parentGroupID).
+		Set("inherit_members = ?", inheritMembers).
+		Set("last_updated = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the parent of group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to update the group at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to update the group at the moment")
+	}
+	model.ParentGroupID = parentGroupID
+	model.InheritMembers = inheritMembers
+
+	if previousParentGroupID != parentGroupID {
+		logGroupEvent(log, &GroupEvent{
+			GroupID:   model.GroupID,
+			ActorID:   actorID,
+			EventType: common.GroupEventParentChanged.GetTypeID(),
+			Details:   parentDetails,
+		})
+	} else if previousInheritMembers != inheritMembers {
+		logGroupEvent(log, &GroupEvent{
+			GroupID:   model.GroupID,
+			ActorID:   actorID,
+			EventType: common.GroupEventSettingsUpdated.GetTypeID(),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildGroupTree(t *testing.T) {
+	groups := []*GroupView{
+		{GroupID: 3, ParentGroupID: 2},
+		{GroupID: 1},
+		{GroupID: 4, ParentGroupID: 99},
+		{GroupID: 2, ParentGroupID: 1},
+		{GroupID: 5, ParentGroupID: 1},
+	}
+
+	// The subgroups follow their parent, the group whose parent is not listed is at the top level
+	wantIDs := []int64{1, 2, 3, 5, 4}
+	wantDepths := []int{0, 1, 2, 1, 0}
+
+	tree := BuildGroupTree(groups)
+	var gotIDs []int64
+	var gotDepths []int
+	for _, node := range tree {
+		gotIDs = append(gotIDs, node.GroupID)
+		gotDepths = append(gotDepths, node.Depth)
+	}
+	if !reflect.DeepEqual(gotIDs, wantIDs) {
+		t.Errorf("got groups %v, want %v", gotIDs, wantIDs)
+	}
+	if !reflect.DeepEqual(gotDepths, wantDepths) {
+		t.Errorf("got depths %v, want %v", gotDepths, wantDepths)
+	}
+	if indent := tree[2].GetIndent(); indent != 4 {
+		t.Errorf("got indent %d for depth 2, want 4", indent)
+	}
+}
+
+func TestBuildGroupTreeEmpty(t *testing.T) {
+	if tree := BuildGroupTree(nil); len(tree) != 0 {
+		t.Errorf("got %d groups, want none", len(tree))
+	}
+}
+
+func TestGetSubgroups(t *testing.T) {
+	groups := []*GroupKeyVal{
+		{GroupID: 1},
+		{GroupID: 2, ParentGroupID: 1},
+		{GroupID: 3, ParentGroupID: 2},
+		{GroupID: 4, ParentGroupID: 1},
+	}
+
+	if got := groupKeyValIDs(GetSubgroups(groups, 1)); !reflect.DeepEqual(got, []int64{2, 4}) {
+		t.Errorf("got subgroups %v, want [2 4]", got)
+	}
+	if got := GetSubgroups(groups, 3); len(got) != 0 {
+		t.Errorf("got subgroups %v, want none", groupKeyValIDs(got))
+	}
+}
+
+func TestGetParentCandidates(t *testing.T) {
+	groups := []*GroupKeyVal{
+		{GroupID: 1},
+		{GroupID: 2, ParentGroupID: 1},
+		{GroupID: 3, ParentGroupID: 2},
+		{GroupID: 4, ParentGroupID: 99},
+		{GroupID: 5, ParentGroupID: 1},
+		// 6 and 7 are parents of each other, the walk up the tree must still stop
+		{GroupID: 6, ParentGroupID: 7},
+		{GroupID: 7, ParentGroupID: 6},
+	}
+
+	tests := []struct {
+		groupID int64
+		want    []int64
+	}{
+		{1, []int64{4, 6, 7}},
+		{2, []int64{1, 4, 5, 6, 7}},
+		{3, []int64{1, 2, 4, 5, 6, 7}},
+		{6, []int64{1, 2, 3, 4, 5}},
+		{42, []int64{1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, test := range tests {
+		got := groupKeyValIDs(GetParentCandidates(groups, test.groupID))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("group %d: got candidates %v, want %v", test.groupID, got, test.want)
+		}
+	}
+}
+
+// groupKeyValIDs returns the IDs of the groups
+func groupKeyValIDs(groups []*GroupKeyVal) []int64 {
+	var groupIDs []int64
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+
+	return groupIDs
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/database"
+)
+
+// GroupLastSeen is the model for the time until which a user has seen the content of a group
+// Items and comments added after LastSeen are shown as new to the user
+type GroupLastSeen struct {
+	tableName struct{}  `sql:"GroupLastSeen,alias:gls"`
+	UserID    int64     `sql:"user_id,pk"`
+	GroupID   int64     `sql:"group_id,pk"`
+	LastSeen  time.Time `sql:"last_seen"`
+}
+
+// MarkGroupsAsSeen moves the last seen time of the user to now for the given groups
+func MarkGroupsAsSeen(log logger.MultiLogger, userID int64, groupIDs []int64) error {
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	watermarks := make([]*GroupLastSeen, len(groupIDs))
+	for index, groupID := range groupIDs {
+		watermarks[index] = &GroupLastSeen{
+			UserID:  userID,
+			GroupID: groupID,
+		}
+	}
+
+	// last_seen defaults to the current time of the database
+	_, err = client.GetPGClient().Model(&watermarks).
+		OnConflict("(user_id, group_id) DO UPDATE").
+		Set("last_seen = EXCLUDED.last_seen").
+		Insert()
+	if err != nil {
+		log.Errorf("Unable to mark the groups as seen for user - %d. Err: %s", userID, err.Error())
+		return fmt.Errorf("Unable to mark the items as seen at the moment")
+	}
+
+	return nil
+}
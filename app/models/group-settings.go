@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// GroupSettings is the model for the settings of a group managed by its leaders
+// AllowedItemTypes is empty when all the item types are allowed
+type GroupSettings struct {
+	tableName        struct{}  `sql:"GroupSettings"`
+	GroupID          int64     `sql:"group_id,pk"`
+	AllowedItemTypes []int     `sql:"allowed_item_types,array"`
+	CommentsEnabled  bool      `sql:"comments_enabled"`
+	UploadApproval   bool      `sql:"upload_approval"`
+	DefaultSort      string    `sql:"default_sort"`
+	UpdatedBy        int64     `sql:"updated_by"`
+	LastUpdated      time.Time `sql:"last_updated"`
+	GroupName        string    `sql:"-"`
+}
+
+// supportedItemTypes are the item types a group can allow
+var supportedItemTypes = []common.ItemType{common.ItemTypePictures, common.ItemTypeVideos}
+
+// defaultGroupSettings returns the settings of a group which were never changed
+func defaultGroupSettings(groupID int64) *GroupSettings {
+	return &GroupSettings{
+		GroupID:          groupID,
+		AllowedItemTypes: []int{},
+		CommentsEnabled:  true,
+		DefaultSort:      common.FeedSortNewest,
+	}
+}
+
+// GetGroupSettings returns the settings of the group, the defaults are returned if the settings were never changed
+func GetGroupSettings(log logger.MultiLogger, groupID int64) (*GroupSettings, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	settings := &GroupSettings{
+		GroupID: groupID,
+	}
+	err = client.GetPGClient().Select(settings)
+	if err == pg.ErrNoRows {
+		return defaultGroupSettings(groupID), nil
+	}
+	if err != nil {
+		log.Errorf("Unable to get the settings of group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the settings of the group")
+	}
+
+	return settings, nil
+}
+
+// IsItemTypeAllowed checks whether items of the type can be uploaded to the group
+func (model *GroupSettings) IsItemTypeAllowed(itemTypeID int) bool {
+	if len(model.AllowedItemTypes) == 0 {
+		return true
+	}
+	for _, allowed := range model.AllowedItemTypes {
+		if allowed == itemTypeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckItemType returns an error if the items of the type cannot be uploaded to the group
+func (model *GroupSettings) CheckItemType(itemType common.ItemType) error {
+	if model.IsItemTypeAllowed(itemType.GetItemID()) {
+		return nil
+	}
+
+	allowed := make([]string, len(model.AllowedItemTypes))
+	for index, itemTypeID := range model.AllowedItemTypes {
+		allowed[index] = common.ItemType(itemTypeID).GetString()
+	}
+	return fmt.Errorf("Items of type '%s' cannot be uploaded to the group. Allowed types - %s",
+		itemType.GetString(), strings.Join(allowed, ", "))
+}
+
+// Update validates and saves the settings of the group
+func (model *GroupSettings) Update(log logger.MultiLogger, actorID int64) error {
+	if len(model.AllowedItemTypes) == 0 {
+		return fmt.Errorf("At least one item type must be allowed in the group")
+	}
+	allowed := make(map[int]bool)
+	for _, itemTypeID := range model.AllowedItemTypes {
+		allowed[itemTypeID] = true
+		supported := false
+		for _, itemType := range supportedItemTypes {
+			if itemType.GetItemID() == itemTypeID {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("Invalid item type - %d", itemTypeID)
+		}
+	}
+	// Allowing every supported item type is stored as no restriction, so that new item types are allowed too
+	if len(allowed) == len(supportedItemTypes) {
+		model.AllowedItemTypes = []int{}
+	}
+
+	switch model.DefaultSort {
+	case common.FeedSortNewest, common.FeedSortOldest, common.FeedSortMostCommented:
+	default:
+		return fmt.Errorf("Invalid sort order - %s", model.DefaultSort)
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	// The row is created with the defaults first, the settings are then set explicitly
+	// since the zero values (e.g. disabled comments) are inserted as the column defaults
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Model(&GroupSettings{GroupID: model.GroupID}).OnConflict("DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model(model).WherePK().
+			Set("allowed_item_types = ?", pg.Array(model.AllowedItemTypes)).
+			Set("comments_enabled = ?", model.CommentsEnabled).
+			Set("upload_approval = ?", model.UploadApproval).
+			Set("default_sort = ?", model.DefaultSort).
+			Set("updated_by = ?", actorID).
+			Set("last_updated = now()").
+			Update()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to update the settings of group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to update the settings at the moment")
+	}
+
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   actorID,
+		EventType: common.GroupEventSettingsUpdated.GetTypeID(),
+	})
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// DirectoryGroup is the display model for the groups listed in the group directory
+type DirectoryGroup struct {
+	tableName          struct{}  `sql:"Groups,alias:group"`
+	GroupID            int64     `sql:"group_id,pk"`
+	GroupName          string    `sql:"group_name"`
+	CreatedByFirstName string    `sql:"created_by_first_name"`
+	CreatedByLastName  string    `sql:"created_by_last_name"`
+	CreationTime       time.Time `sql:"creation_time"`
+	Visibility         int       `sql:"visibility"`
+	IsArchived         bool      `sql:"is_archived"`
+	MemberCount        int       `sql:"member_count"`
+	ItemCount          int       `sql:"item_count"`
+	IsMember           bool      `sql:"is_member"`
+	HasPendingRequest  bool      `sql:"has_pending_request"`
+}
+
+// IsPublic returns whether the content of the group can be viewed without joining it
+func (model *DirectoryGroup) IsPublic() bool {
+	return model.Visibility == common.GroupVisibilityPublic.GetVisibilityID()
+}
+
+// GetVisibilityName returns the name of the visibility of the group
+func (model *DirectoryGroup) GetVisibilityName() string {
+	return common.GroupVisibility(model.Visibility).GetString()
+}
+
+// GetGroupDirectory returns the approved groups listed in the directory (public and listed groups)
+// along with the number of their members and items
+func GetGroupDirectory(log logger.MultiLogger, userID int64) ([]*DirectoryGroup, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var groups []*DirectoryGroup
+	err = client.GetPGClient().Model(&groups).
+		ColumnExpr(`"group".group_id, "group".group_name, "group".creation_time, "group".visibility, "group".is_archived`).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		ColumnExpr(`(SELECT count(*) FROM usergroupmap AS ugm WHERE ugm.group_id = "group".group_id) AS member_count`).
+		ColumnExpr(`(SELECT count(*) FROM items AS i WHERE i.group_id = "group".group_id AND i.uploaded AND i.approval_status = ?0) AS item_count`,
+			common.ItemApprovalPublished.GetStatusID()).
+		ColumnExpr(`EXISTS (SELECT 1 FROM usergroupmap AS ugm WHERE ugm.group_id = "group".group_id AND ugm.user_id = ?0) AS is_member`, userID).
+		ColumnExpr(`EXISTS (SELECT 1 FROM groupjoinrequests AS jr WHERE jr.group_id = "group".group_id AND jr.user_id = ?0 AND jr.workflow_status = ?1) AS has_pending_request`,
+			userID, common.WorkflowStatusPending.GetStatusID()).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"group\".created_by").
+		Where("\"group\".workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+		Where("\"group\".visibility <> ?", common.GroupVisibilityHidden.GetVisibilityID()).
+		OrderExpr(`"group".group_name ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the group directory for user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the groups")
+	}
+
+	return groups, nil
+}
+
+// SetVisibility changes who can discover and read the group
+func (model *Group) SetVisibility(log logger.MultiLogger, visibility common.GroupVisibility, actorID int64) error {
+	if !visibility.IsValid() {
+		return fmt.Errorf("Invalid visibility for the group")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("visibility = ?", visibility.GetVisibilityID()).
+		Set("last_updated = now()").
+		Where("visibility <> ?", visibility.GetVisibilityID()).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the visibility of group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to update the group at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The visibility of the group is already %s", visibility.GetString())
+	}
+	model.Visibility = visibility.GetVisibilityID()
+
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   actorID,
+		EventType: common.GroupEventVisibilityChanged.GetTypeID(),
+		Details:   visibility.GetString(),
+	})
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/revel/revel/logger"
 	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/database"
@@ -20,14 +21,22 @@ type Group struct {
 	WorkflowStatus int       `sql:"workflow_status"`
 	MaxItemCount   int       `sql:"max_item_count"`
 	MaxItemSpace   float32   `sql:"max_item_space"`
+	IsArchived     bool      `sql:"is_archived,default:false"`
+	Visibility     int       `sql:"visibility"`
+	ParentGroupID  int64     `sql:"parent_group_id"`
+	InheritMembers bool      `sql:"inherit_members"`
 }
 
 // GroupKeyVal is the model for group key-val list (used for dropdowns etc)
+// IsMember is false for the public groups and the inherited subgroups the user can read without being a member
 type GroupKeyVal struct {
-	tableName struct{} `sql:"Groups,alias:group"`
-	GroupID   int64    `sql:"group_id,pk"`
-	GroupName string   `sql:"group_name"`
-	CreatedBy int64    `sql:"created_by"`
+	tableName     struct{} `sql:"Groups,alias:group"`
+	GroupID       int64    `sql:"group_id,pk"`
+	GroupName     string   `sql:"group_name"`
+	CreatedBy     int64    `sql:"created_by"`
+	IsArchived    bool     `sql:"is_archived"`
+	IsMember      bool     `sql:"is_member"`
+	ParentGroupID int64    `sql:"parent_group_id"`
 }
 
 // GroupView is the group view model
@@ -40,6 +49,9 @@ type GroupView struct {
 	CreationTime       time.Time `sql:"creation_time"`
 	WorkflowStatus     int       `sql:"workflow_status"`
 	IsLeader           bool      `sql:"is_leader"`
+	IsArchived         bool      `sql:"is_archived"`
+	IsMember           bool      `sql:"is_member"`
+	ParentGroupID      int64     `sql:"parent_group_id"`
 }
 
 // GroupDetails is the group view model
@@ -47,13 +59,74 @@ type GroupDetails struct {
 	tableName             struct{}  `sql:"Groups,alias:group"`
 	GroupID               int64     `sql:"group_id,pk"`
 	GroupName             string    `sql:"group_name"`
+	CreatedBy             int64     `sql:"created_by"`
 	CreatedByFirstName    string    `sql:"created_by_first_name"`
 	CreatedByLastName     string    `sql:"created_by_last_name"`
 	CreationTime          time.Time `sql:"creation_time"`
 	WorkflowStatus        int       `sql:"workflow_status"`
 	UserMapWorkflowStatus int       `sql:"user_map_workflow_status"`
-	IsLeader              bool      `sql:"is_leader"`
+	Role                  int       `sql:"role"`
+	IsArchived            bool      `sql:"is_archived"`
+	Visibility            int       `sql:"visibility"`
+	ParentGroupID         int64     `sql:"parent_group_id"`
+	ParentGroupName       string    `sql:"parent_group_name"`
+	InheritMembers        bool      `sql:"inherit_members"`
+	IsMember              bool      `sql:"-"`
+	IsAdmin               bool      `sql:"-"`
+	UserID                int64     `sql:"-"`
 	TaggedUsers           []*UserGroupMapView
+	Albums                []*AlbumView
+	Invitations           []*InvitationView
+	JoinRequests          []*JoinRequestView
+	Subgroups             []*GroupKeyVal
+	ParentCandidates      []*GroupKeyVal
+}
+
+// IsLeader returns whether the user viewing the group is an approved leader (or an admin)
+func (model *GroupDetails) IsLeader() bool {
+	return model.Role == common.GroupRoleLeader.GetRoleID()
+}
+
+// IsOwner returns whether the user viewing the group is its owner
+func (model *GroupDetails) IsOwner() bool {
+	return model.CreatedBy == model.UserID
+}
+
+// CanTransferOwnership returns whether the user viewing the group can hand it over to another member
+func (model *GroupDetails) CanTransferOwnership() bool {
+	return model.IsOwner() || model.IsAdmin
+}
+
+// GetRoleName returns the name of the role of the user viewing the group
+func (model *GroupDetails) GetRoleName() string {
+	return common.GroupRole(model.Role).GetString()
+}
+
+// CanManageMembers returns whether the user viewing the group can manage its members
+func (model *GroupDetails) CanManageMembers() bool {
+	return common.GroupRole(model.Role).Can(common.PermissionManageMembers)
+}
+
+// CanManageSettings returns whether the user viewing the group can manage its settings
+func (model *GroupDetails) CanManageSettings() bool {
+	return common.GroupRole(model.Role).Can(common.PermissionManageSettings)
+}
+
+// CanApproveItems returns whether the user viewing the group can approve the items pending for approval
+func (model *GroupDetails) CanApproveItems() bool {
+	return common.GroupRole(model.Role).Can(common.PermissionApproveItems)
+}
+
+// GetVisibilityName returns the name of the visibility of the group
+func (model *GroupDetails) GetVisibilityName() string {
+	return common.GroupVisibility(model.Visibility).GetString()
+}
+
+// GroupGallery is the view model for a page of the items of a group
+type GroupGallery struct {
+	Query     *FeedQuery
+	Uploaders []*UserKeyVal
+	Page      *FeedPage
 }
 
 // GroupLimits is the view model for setting group level limits
@@ -87,7 +160,7 @@ func (model *Group) Add(log logger.MultiLogger) error {
 		UserID:         model.CreatedBy,
 		GroupID:        model.GroupID,
 		CreatedBy:      model.CreatedBy,
-		IsLeader:       true,
+		Role:           common.GroupRoleLeader.GetRoleID(),
 		WorkflowStatus: common.WorkflowStatusApproved.GetStatusID(),
 	}
 
@@ -126,21 +199,37 @@ func GetGroupDetails(log logger.MultiLogger, userID int64, groupID int64) (*Grou
 	query := client.GetPGClient().Model(group)
 
 	if !userModel.IsAdmin {
-		// non-admin user
-		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time`).
-			ColumnExpr(`ugm.is_leader, ugm.workflow_status AS user_map_workflow_status`).
+		// non-admin user, the public groups can be viewed by the approved users who are not members (role none)
+		// and the inherited subgroups by the members of their ancestor groups
+		inheritedGroupIDs, err := getInheritedGroupIDs(userID)
+		if err != nil {
+			log.Errorf(err.Error())
+			return nil, fmt.Errorf("Unable to process the request")
+		}
+
+		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time, "group".is_archived, "group".created_by, "group".visibility`).
+			ColumnExpr(`"group".parent_group_id, "group".inherit_members, parent.group_name AS parent_group_name`).
+			ColumnExpr(`coalesce(ugm.role, ?) AS role, ugm.workflow_status AS user_map_workflow_status`, common.GroupRoleNone.GetRoleID()).
 			ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
-			Join("JOIN usergroupmap AS ugm").
+			Join("LEFT JOIN usergroupmap AS ugm").
 			JoinOn("ugm.group_id = \"group\".group_id").
+			JoinOn("ugm.user_id = ?", userID).
+			Join("LEFT JOIN groups AS parent").
+			JoinOn("parent.group_id = \"group\".parent_group_id").
 			Join("JOIN appuser AS u").
 			JoinOn("u.user_id = \"group\".created_by").
 			Where("\"group\".group_id = ?", groupID).
-			Where("ugm.user_id = ?", userID)
+			Where("(ugm.user_id IS NOT NULL OR \"group\".group_id = ANY(?0) OR (\"group\".visibility = ?1 AND \"group\".workflow_status = ?2 AND ?3))",
+				pg.Array(inheritedGroupIDs), common.GroupVisibilityPublic.GetVisibilityID(), common.WorkflowStatusApproved.GetStatusID(), userModel.IsApproved())
 	} else {
 		// admin
-		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time`).
-			ColumnExpr(`true AS is_leader, 1 AS user_map_workflow_status`).
+		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time, "group".is_archived, "group".created_by, "group".visibility`).
+			ColumnExpr(`"group".parent_group_id, "group".inherit_members, parent.group_name AS parent_group_name`).
+			ColumnExpr(`?0 AS role, ?1 AS user_map_workflow_status`,
+				common.GroupRoleLeader.GetRoleID(), common.WorkflowStatusApproved.GetStatusID()).
 			ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+			Join("LEFT JOIN groups AS parent").
+			JoinOn("parent.group_id = \"group\".parent_group_id").
 			Join("JOIN appuser AS u").
 			JoinOn("u.user_id = \"group\".created_by").
 			Where("\"group\".group_id = ?", groupID)
@@ -155,9 +244,9 @@ func GetGroupDetails(log logger.MultiLogger, userID int64, groupID int64) (*Grou
 		return nil, fmt.Errorf("The group does not exist")
 	}
 
-	if group.UserMapWorkflowStatus == common.WorkflowStatusPending.GetStatusID() {
+	if group.IsLeader() && group.UserMapWorkflowStatus == common.WorkflowStatusPending.GetStatusID() {
 		log.Info("Marking the user as not a leader")
-		group.IsLeader = false
+		group.Role = common.GroupRoleContributor.GetRoleID()
 	}
 
 	// Get all the users tagged to the group
@@ -167,11 +256,29 @@ func GetGroupDetails(log logger.MultiLogger, userID int64, groupID int64) (*Grou
 	}
 	group.TaggedUsers = users
 
+	// Admins can view the groups they are not a member of
+	group.UserID = userID
+	group.IsAdmin = userModel.IsAdmin
+	for _, user := range users {
+		if user.UserID == userID {
+			group.IsMember = true
+			break
+		}
+	}
+
+	// Get all the albums of the group
+	albums, err := GetAlbumsForGroup(log, groupID)
+	if err != nil {
+		return group, err
+	}
+	group.Albums = albums
+
 	return group, err
 }
 
 // UpdateLimits updates the item upload limits associated with a group
-func (model *Group) UpdateLimits(log logger.MultiLogger) error {
+// actorID is the admin updating the limits
+func (model *Group) UpdateLimits(log logger.MultiLogger, actorID int64) error {
 	// Get Database client
 	client, err := database.GetClient()
 	if err != nil {
@@ -194,10 +301,206 @@ func (model *Group) UpdateLimits(log logger.MultiLogger) error {
 		return fmt.Errorf("Unable to update group limits")
 	}
 
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   actorID,
+		EventType: common.GroupEventLimitsChanged.GetTypeID(),
+		Details:   fmt.Sprintf("max items: %d, max space: %.2f MB", model.MaxItemCount, model.MaxItemSpace),
+	})
+
+	return nil
+}
+
+// Rename changes the name of the group, the name is unique across the groups
+func (model *Group) Rename(log logger.MultiLogger, groupName string, actorID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	existing := &Group{
+		GroupID: model.GroupID,
+	}
+	err = client.GetPGClient().Select(existing)
+	if err != nil {
+		log.Errorf("Unable to get the group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("The group does not exist")
+	}
+	if existing.GroupName == groupName {
+		return nil
+	}
+
+	count, err := client.GetPGClient().Model((*Group)(nil)).
+		Where("group_name = ?", groupName).
+		Where("group_id <> ?", model.GroupID).
+		Count()
+	if err != nil {
+		log.Errorf("Unable to check the name of the group. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+	if count > 0 {
+		return fmt.Errorf("A group with the name '%s' already exists", groupName)
+	}
+
+	model.GroupName = groupName
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("group_name = ?", groupName).
+		Set("last_updated = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to rename the group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to rename the group at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to rename the group at the moment")
+	}
+
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   actorID,
+		EventType: common.GroupEventRenamed.GetTypeID(),
+		Details:   fmt.Sprintf("from '%s' to '%s'", existing.GroupName, groupName),
+	})
+
 	return nil
 }
 
-// GetAllGroups returns list of all the groups that user has access to
+// TransferOwnership hands the group over to another member, the new owner is made an approved leader of the group
+// (settling a pending request for the group lead access). The previous owner stays a leader of the group
+func (model *Group) TransferOwnership(log logger.MultiLogger, newOwnerID, actorID int64) error {
+	// Note: Authz check is already done at this point
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().Select(model)
+	if err != nil {
+		log.Errorf("Unable to get the group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("The group does not exist")
+	}
+	if model.CreatedBy == newOwnerID {
+		return fmt.Errorf("The user is already the owner of the group")
+	}
+
+	membership := &UserGroupMap{
+		UserID:  newOwnerID,
+		GroupID: model.GroupID,
+	}
+	err = client.GetPGClient().Select(membership)
+	if err == pg.ErrNoRows {
+		return fmt.Errorf("The ownership can be transferred only to a member of the group")
+	}
+	if err != nil {
+		log.Errorf("Unable to fetch the user-group mapping. Error: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	previousOwnerID := model.CreatedBy
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(model).WherePK().
+			Set("created_by = ?", newOwnerID).
+			Set("last_updated = now()").
+			Where("created_by = ?", previousOwnerID).
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return pg.ErrNoRows
+		}
+
+		_, err = tx.Model(membership).WherePK().
+			Set("role = ?", common.GroupRoleLeader.GetRoleID()).
+			Set("workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+			Set("last_updated = now()").
+			Update()
+		return err
+	})
+	if err == pg.ErrNoRows {
+		return fmt.Errorf("The ownership of the group was changed in the meantime. Please try again")
+	}
+	if err != nil {
+		log.Errorf("Unable to transfer the ownership of group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to transfer the ownership at the moment")
+	}
+	model.CreatedBy = newOwnerID
+
+	if membership.GetRole() != common.GroupRoleLeader {
+		logGroupEvent(log, &GroupEvent{
+			GroupID:      model.GroupID,
+			ActorID:      actorID,
+			EventType:    common.GroupEventLeaderPromoted.GetTypeID(),
+			TargetUserID: newOwnerID,
+		})
+	}
+	logGroupEvent(log, &GroupEvent{
+		GroupID:      model.GroupID,
+		ActorID:      actorID,
+		EventType:    common.GroupEventOwnershipTransferred.GetTypeID(),
+		TargetUserID: newOwnerID,
+	})
+
+	return nil
+}
+
+// SetArchived archives the group or restores an archived group
+// An archived group is read-only, its content stays visible but no items or comments can be added
+func (model *Group) SetArchived(log logger.MultiLogger, archived bool, actorID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("is_archived = ?", archived).
+		Set("last_updated = now()").
+		Where("is_archived = ?", !archived).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the archive status of group - %d. Err: %s", model.GroupID, err.Error())
+		return fmt.Errorf("Unable to update the group at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		if archived {
+			return fmt.Errorf("The group is already archived")
+		}
+		return fmt.Errorf("The group is not archived")
+	}
+	model.IsArchived = archived
+
+	eventType := common.GroupEventArchived
+	if !archived {
+		eventType = common.GroupEventRestored
+	}
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   actorID,
+		EventType: eventType.GetTypeID(),
+	})
+
+	return nil
+}
+
+// IsGroupArchived checks whether the group is archived (read-only)
+func IsGroupArchived(log logger.MultiLogger, groupID int64) (bool, error) {
+	group, err := GetGroupDetailUsingID(groupID)
+	if err != nil {
+		log.Errorf("Unable to get the group - %d. Err: %s", groupID, err.Error())
+		return false, fmt.Errorf("The group does not exist")
+	}
+
+	return group.IsArchived, nil
+}
+
+// GetAllGroups returns list of all the groups that user has access to, including the inherited subgroups
 // Approved as well as pending groups are returned
 func GetAllGroups(userID int64) ([]*GroupView, error) {
 	var groups []*GroupView
@@ -217,23 +520,30 @@ func GetAllGroups(userID int64) ([]*GroupView, error) {
 	query := client.GetPGClient().Model(&groups)
 
 	if !userModel.IsAdmin {
+		inheritedGroupIDs, err := getInheritedGroupIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+
 		// Get only those groups in which user has access
-		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time`).
-			ColumnExpr(`ugm.is_leader`).
+		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time, "group".is_archived, "group".parent_group_id`).
+			ColumnExpr(`coalesce(ugm.role = ?, false) AS is_leader`, common.GroupRoleLeader.GetRoleID()).
+			ColumnExpr(`ugm.user_id IS NOT NULL AS is_member`).
 			ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
-			Join("JOIN usergroupmap AS ugm").
+			Join("LEFT JOIN usergroupmap AS ugm").
 			JoinOn("ugm.group_id = \"group\".group_id").
+			JoinOn("ugm.user_id = ?", userID).
 			Join("JOIN appuser AS u").
 			JoinOn("u.user_id = \"group\".created_by").
-			Where("ugm.user_id = ?", userID)
+			Where("(ugm.user_id IS NOT NULL OR \"group\".group_id = ANY(?))", pg.Array(inheritedGroupIDs))
 	} else {
-		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time`).
-			ColumnExpr(`true AS is_leader`).
+		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".workflow_status, "group".creation_time, "group".is_archived, "group".parent_group_id`).
+			ColumnExpr(`true AS is_leader, true AS is_member`).
 			ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
 			Join("JOIN appuser AS u").
 			JoinOn("u.user_id = \"group\".created_by")
 	}
-	err = query.Select()
+	err = query.OrderExpr(`"group".group_name ASC`).Select()
 
 	return groups, err
 }
@@ -323,8 +633,8 @@ func (model *Group) ApproveOrReject(log logger.MultiLogger, approve bool) error
 	return nil
 }
 
-// GetAllGroupsKeyVal returns list of all the groups that user has access to
-// Only approved groups are returned
+// GetAllGroupsKeyVal returns list of all the groups that user has access to, including the public groups
+// and the inherited subgroups. Only approved groups are returned
 func GetAllGroupsKeyVal(userID int64) ([]*GroupKeyVal, error) {
 	var groups []*GroupKeyVal
 
@@ -342,14 +652,24 @@ func GetAllGroupsKeyVal(userID int64) ([]*GroupKeyVal, error) {
 
 	query := client.GetPGClient().Model(&groups)
 	if !userModel.IsAdmin {
-		query = query.ColumnExpr(`"group".group_id, "group".group_name`).
-			Join("JOIN usergroupmap AS ugm").
+		inheritedGroupIDs, err := getInheritedGroupIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The public groups are readable by all the approved users
+		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".is_archived, "group".parent_group_id`).
+			ColumnExpr(`ugm.user_id IS NOT NULL AS is_member`).
+			Join("LEFT JOIN usergroupmap AS ugm").
 			JoinOn("ugm.group_id = \"group\".group_id").
+			JoinOn("ugm.user_id = ?", userID).
 			Where("\"group\".workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
-			Where("ugm.user_id = ?", userID).
+			Where("(ugm.user_id IS NOT NULL OR \"group\".group_id = ANY(?0) OR (\"group\".visibility = ?1 AND ?2))",
+				pg.Array(inheritedGroupIDs), common.GroupVisibilityPublic.GetVisibilityID(), userModel.IsApproved()).
 			Order("group_name ASC")
 	} else {
-		query = query.ColumnExpr(`"group".group_id, "group".group_name`).
+		query = query.ColumnExpr(`"group".group_id, "group".group_name, "group".is_archived, "group".parent_group_id`).
+			ColumnExpr(`true AS is_member`).
 			Where("\"group\".workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
 			Order("group_name ASC")
 	}
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/base64"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/revel/revel/logger"
 	"github.com/sp-share/app/common"
@@ -9,77 +13,171 @@ import (
 
 // HomeView is the model for the home page
 type HomeView struct {
-	Images []*ItemInGroup
-	Videos []*ItemInGroup
+	Groups []*GroupKeyVal
+	Query  *FeedQuery
+	Feed   *FeedPage
 }
 
-// ItemInGroup contains item metadata along with group tagging
-type ItemInGroup struct {
-	GroupDetails *GroupKeyVal
-	ItemMeta     *Item
+// FeedQuery holds the filters, sort order and cursor for a page of the item feed
+type FeedQuery struct {
+	GroupIDs   []int64
+	GroupID    int64
+	ItemTypeID int
+	UploaderID int64
+	Sort       string
+	Cursor     string
+	Limit      int
+	// UserID is set to mark the items and comments the user has not seen yet
+	UserID     int64
+	UnseenOnly bool
 }
 
-// GetHomePageData get the data for home page for the logged in user
-func GetHomePageData(log logger.MultiLogger, userID int64) (*HomeView, error) {
-	homeViewModel := &HomeView{}
+// FeedItem is the model for an item listed in the feed
+type FeedItem struct {
+	tableName          struct{}           `sql:"Items,alias:item"`
+	ItemID             int64              `sql:"item_id,pk" json:"itemID"`
+	ItemName           string             `sql:"item_name" json:"itemName"`
+	Description        string             `sql:"description" json:"description"`
+	ItemTypeID         int                `sql:"item_type_id" json:"itemTypeID"`
+	GroupID            int64              `sql:"group_id" json:"groupID"`
+	GroupName          string             `sql:"group_name" json:"groupName"`
+	ItemPath           string             `sql:"item_path" json:"itemPath"`
+	CreatedByFirstName string             `sql:"created_by_first_name" json:"createdByFirstName"`
+	CreatedByLastName  string             `sql:"created_by_last_name" json:"createdByLastName"`
+	CreationTime       time.Time          `sql:"creation_time" json:"creationTime"`
+	CommentCount       int                `sql:"comment_count" json:"commentCount"`
+	IsNew              bool               `sql:"is_new" json:"isNew"`
+	NewCommentCount    int                `sql:"new_comment_count" json:"newCommentCount"`
+	TopReactions       []*ReactionSummary `sql:"-" json:"topReactions"`
+}
+
+// FeedPage is a page of the item feed
+// NextCursor is empty when there are no more items
+type FeedPage struct {
+	Items      []*FeedItem `json:"items"`
+	NextCursor string      `json:"nextCursor"`
+}
 
+// feedCursor is the position of the last item of a feed page
+type feedCursor struct {
+	ItemID       int64
+	CreationTime time.Time
+	CommentCount int
+}
+
+// NewFeedQuery validates the feed filters against the groups of the user and builds the feed query
+func NewFeedQuery(groups []*GroupKeyVal, groupID int64, itemTypeID int, sort, cursor string) (*FeedQuery, error) {
+	feedQuery := &FeedQuery{
+		GroupID:    groupID,
+		ItemTypeID: itemTypeID,
+		Sort:       sort,
+		Cursor:     cursor,
+		Limit:      common.FeedPageSize,
+	}
+
+	switch sort {
+	case common.FeedSortNewest, common.FeedSortOldest, common.FeedSortMostCommented:
+	case "":
+		feedQuery.Sort = common.FeedSortNewest
+	default:
+		return nil, fmt.Errorf("Invalid sort order - %s", sort)
+	}
+
+	// The feed across the groups lists the groups the user is a member of, the public groups are browsed one at a time
+	for _, group := range groups {
+		if (groupID == 0 && group.IsMember) || group.GroupID == groupID {
+			feedQuery.GroupIDs = append(feedQuery.GroupIDs, group.GroupID)
+		}
+	}
+	if groupID > 0 && len(feedQuery.GroupIDs) == 0 {
+		return nil, fmt.Errorf("Unauthorized! You do not have enough permissions to view the content")
+	}
+
+	return feedQuery, nil
+}
+
+// GetHomePageData get the data for home page for the logged in user
+// unseenOnly limits the feed to the items added since the user last marked the groups as seen
+func GetHomePageData(log logger.MultiLogger, userID int64, groupID int64, itemTypeID int, sort string, unseenOnly bool) (*HomeView, error) {
 	// Get all the groups for the logged in user
 	groups, err := GetAllGroupsKeyVal(userID)
 	if err != nil {
-		// We already logged this error
-		return nil, err
+		log.Errorf("Unable to get the groups for user - %d. Error: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the item metadata")
 	}
 	if len(groups) == 0 {
 		// There are no groups present at this point
 		return nil, nil
 	}
 
-	groupKeyValMap := make(map[int64]*GroupKeyVal)
+	feedQuery, err := NewFeedQuery(groups, groupID, itemTypeID, sort, "")
+	if err != nil {
+		return nil, err
+	}
+	feedQuery.UserID = userID
+	feedQuery.UnseenOnly = unseenOnly
+
+	// Get the first page of items
+	feedPage, err := GetItemsByGroupIDs(log, feedQuery)
+	if err != nil {
+		// We already logged this error
+		return nil, err
+	}
+
+	homeViewModel := &HomeView{
+		Groups: groups,
+		Query:  feedQuery,
+		Feed:   feedPage,
+	}
 
-	// Fetch all the group IDs
-	groupIDs := make([]int64, len(groups))
-	for index, group := range groups {
-		groupIDs[index] = group.GroupID
+	return homeViewModel, nil
+}
 
-		// Add to mapping for id->name for group
-		groupKeyValMap[group.GroupID] = group
+// encodeFeedCursor encodes the position of the item in the given sort order as an opaque cursor
+func encodeFeedCursor(item *FeedItem, sort string) string {
+	var value string
+	switch sort {
+	case common.FeedSortMostCommented:
+		value = strconv.Itoa(item.CommentCount)
+	default:
+		value = item.CreationTime.UTC().Format(time.RFC3339Nano)
 	}
 
-	// Get all items
-	items, err := GetItemsByGroupIDs(groupIDs)
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s|%d", value, item.ItemID)))
+}
+
+// decodeFeedCursor decodes a cursor generated by encodeFeedCursor for the given sort order
+// nil is returned for an empty cursor (first page)
+func decodeFeedCursor(cursor, sort string) (*feedCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		log.Errorf("Unable to get item metadata from database. Error: %+v", err)
-		return nil, fmt.Errorf("Unable to fetch the item metadata")
+		return nil, err
 	}
 
-	for _, item := range items {
-		// Get the group object
-		groupObj, present := groupKeyValMap[item.GroupID]
-		if !present {
-			// This should not happen
-			// If it does, we'll skip this item and log the error
-			log.Errorf("Didn't find the group object in the group map [GroupID: %d]", item.GroupID)
-			continue
-		}
+	parts := strings.Split(string(decoded), "|")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Malformed cursor")
+	}
 
-		itemInGroup := &ItemInGroup{
-			GroupDetails: groupObj,
-			ItemMeta:     item,
-		}
+	feedCursor := &feedCursor{}
+	feedCursor.ItemID, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
 
-		switch item.ItemTypeID {
-		case common.ItemTypePictures.GetItemID():
-			homeViewModel.Images = append(
-				homeViewModel.Images,
-				itemInGroup,
-			)
-		case common.ItemTypeVideos.GetItemID():
-			homeViewModel.Videos = append(
-				homeViewModel.Videos,
-				itemInGroup,
-			)
-		}
+	switch sort {
+	case common.FeedSortMostCommented:
+		feedCursor.CommentCount, err = strconv.Atoi(parts[0])
+	default:
+		feedCursor.CreationTime, err = time.Parse(time.RFC3339Nano, parts[0])
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return homeViewModel, nil
+	return feedCursor, nil
 }
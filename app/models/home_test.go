@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/sp-share/app/common"
+)
+
+func TestFeedCursorRoundTrip(t *testing.T) {
+	item := &FeedItem{
+		ItemID:       42,
+		CreationTime: time.Date(2020, time.March, 14, 15, 9, 26, 535897932, time.FixedZone("IST", 19800)),
+		CommentCount: 7,
+	}
+
+	for _, sort := range []string{common.FeedSortNewest, common.FeedSortOldest, ""} {
+		cursor, err := decodeFeedCursor(encodeFeedCursor(item, sort), sort)
+		if err != nil {
+			t.Fatalf("%q: unexpected error - %s", sort, err.Error())
+		}
+		if cursor.ItemID != item.ItemID {
+			t.Errorf("%q: got item %d, want %d", sort, cursor.ItemID, item.ItemID)
+		}
+		if !cursor.CreationTime.Equal(item.CreationTime) {
+			t.Errorf("%q: got time %s, want %s", sort, cursor.CreationTime, item.CreationTime)
+		}
+	}
+
+	cursor, err := decodeFeedCursor(encodeFeedCursor(item, common.FeedSortMostCommented), common.FeedSortMostCommented)
+	if err != nil {
+		t.Fatalf("unexpected error - %s", err.Error())
+	}
+	if cursor.ItemID != item.ItemID || cursor.CommentCount != item.CommentCount {
+		t.Errorf("got item %d with %d comments, want item %d with %d comments",
+			cursor.ItemID, cursor.CommentCount, item.ItemID, item.CommentCount)
+	}
+}
+
+func TestDecodeFeedCursorEmpty(t *testing.T) {
+	cursor, err := decodeFeedCursor("", common.FeedSortNewest)
+	if err != nil || cursor != nil {
+		t.Errorf("got %v, %v for the first page, want nil, nil", cursor, err)
+	}
+}
+
+func TestDecodeFeedCursorInvalid(t *testing.T) {
+	encode := func(value string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"not base64", "%%%", common.FeedSortNewest},
+		{"no separator", encode("42"), common.FeedSortNewest},
+		{"extra separator", encode("1|2|3"), common.FeedSortNewest},
+		{"invalid item id", encode("2020-03-14T15:09:26Z|abc"), common.FeedSortNewest},
+		{"invalid time", encode("yesterday|42"), common.FeedSortNewest},
+		{"invalid count", encode("many|42"), common.FeedSortMostCommented},
+		{"time cursor for the count sort", encode("2020-03-14T15:09:26Z|42"), common.FeedSortMostCommented},
+		{"count cursor for the time sort", encode("7|42"), common.FeedSortOldest},
+	}
+
+	for _, test := range tests {
+		cursor, err := decodeFeedCursor(test.cursor, test.sort)
+		if err == nil {
+			t.Errorf("%s: expected an error, got %+v", test.name, cursor)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// Invitation is the model for the invitations to join a group
+// Registered users are invited by UserID, an unregistered email is invited through a signup link with the token
+type Invitation struct {
+	tableName    struct{}  `sql:"GroupInvitations,alias:gi"`
+	InvitationID int64     `sql:"invitation_id,pk"`
+	Token        string    `sql:"token"`
+	GroupID      int64     `sql:"group_id"`
+	UserID       int64     `sql:"user_id"`
+	Email        string    `sql:"email"`
+	InvitedBy    int64     `sql:"invited_by"`
+	Status       int       `sql:"status"`
+	ExpiresAt    time.Time `sql:"expires_at"`
+	CreationTime time.Time `sql:"creation_time"`
+	RespondedAt  time.Time `sql:"responded_at"`
+}
+
+// InvitationView is the display model for the invitations
+type InvitationView struct {
+	tableName          struct{}  `sql:"GroupInvitations,alias:gi"`
+	InvitationID       int64     `sql:"invitation_id,pk"`
+	Token              string    `sql:"token"`
+	GroupID            int64     `sql:"group_id"`
+	GroupName          string    `sql:"group_name"`
+	UserID             int64     `sql:"user_id"`
+	Email              string    `sql:"email"`
+	Username           string    `sql:"username"`
+	FirstName          string    `sql:"first_name"`
+	LastName           string    `sql:"last_name"`
+	InvitedByFirstName string    `sql:"invited_by_first_name"`
+	InvitedByLastName  string    `sql:"invited_by_last_name"`
+	Status             int       `sql:"status"`
+	ExpiresAt          time.Time `sql:"expires_at"`
+	CreationTime       time.Time `sql:"creation_time"`
+}
+
+// IsExpired returns whether the invitation can no longer be accepted
+func (model *Invitation) IsExpired() bool {
+	return time.Now().After(model.ExpiresAt)
+}
+
+// IsExpired returns whether the invitation can no longer be accepted
+func (model *InvitationView) IsExpired() bool {
+	return time.Now().After(model.ExpiresAt)
+}
+
+// GetStatus returns the text of the status of the invitation
+func (model *InvitationView) GetStatus() string {
+	if model.Status == common.InvitationPending.GetStatusID() && model.IsExpired() {
+		return "Expired"
+	}
+	return common.InvitationStatus(model.Status).GetString()
+}
+
+// InviteToGroup invites the user with the given username or email to the group
+// An email that does not belong to a registered user gets an invitation with a signup link
+func InviteToGroup(log logger.MultiLogger, groupID, invitedBy int64, invitee string) (*Invitation, error) {
+	invitee = strings.ToLower(strings.TrimSpace(invitee))
+	if invitee == "" {
+		return nil, fmt.Errorf("Username or email is required")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	invitation := &Invitation{
+		GroupID:   groupID,
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().AddDate(0, 0, common.InvitationExpiryDays),
+	}
+
+	user := &User{}
+	query := client.GetPGClient().Model(user)
+	if strings.Contains(invitee, "@") {
+		query = query.Where("lower(email) = ?", invitee).Order("user_id ASC").Limit(1)
+	} else {
+		query = query.Where("username = ?", invitee)
+	}
+	err = query.Select()
+	if err == pg.ErrNoRows {
+		if !strings.Contains(invitee, "@") {
+			return nil, fmt.Errorf("Invalid username provided")
+		}
+		invitation.Email = invitee
+	} else if err != nil {
+		log.Errorf("Unable to get the invitee - %s. Err: %s", invitee, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	} else {
+		invitation.UserID = user.UserID
+
+		count, err := client.GetPGClient().Model((*UserGroupMap)(nil)).
+			Where("user_id = ?", user.UserID).
+			Where("group_id = ?", groupID).
+			Count()
+		if err != nil {
+			log.Errorf("Unable to check the membership of user - %d in group - %d. Err: %s", user.UserID, groupID, err.Error())
+			return nil, fmt.Errorf("Unable to process the request")
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("The user is already a member of the group")
+		}
+	}
+
+	// Only one pending invitation per invitee
+	query = client.GetPGClient().Model((*Invitation)(nil)).
+		Where("group_id = ?", groupID).
+		Where("status = ?", common.InvitationPending.GetStatusID()).
+		Where("expires_at > now()")
+	if invitation.UserID > 0 {
+		query = query.Where("user_id = ?", invitation.UserID)
+	} else {
+		query = query.Where("email = ?", invitation.Email)
+	}
+	count, err := query.Count()
+	if err != nil {
+		log.Errorf("Unable to check the invitations of group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("'%s' already has a pending invitation to the group", invitee)
+	}
+
+	err = invitation.Add(log)
+	if err != nil {
+		// error is already logged
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Add creates the invitation with a new random token
+func (model *Invitation) Add(log logger.MultiLogger) error {
+	token, err := common.RandomToken(common.InvitationTokenBytes)
+	if err != nil {
+		log.Errorf("Unable to generate the token for the invitation. Err: %s", err.Error())
+		return fmt.Errorf("Unable to create the invitation at the moment")
+	}
+	model.Token = token
+	model.Status = common.InvitationPending.GetStatusID()
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).Returning("*").Insert()
+	if err != nil {
+		log.Errorf("Unable to insert the invitation into database. Err: %s", err.Error())
+		return fmt.Errorf("Unable to create the invitation at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to create the invitation at the moment")
+	}
+
+	return nil
+}
+
+// GetInvitationByID returns the invitation with the given ID
+func GetInvitationByID(log logger.MultiLogger, invitationID int64) (*Invitation, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	invitation := &Invitation{
+		InvitationID: invitationID,
+	}
+	err = client.GetPGClient().Select(invitation)
+	if err != nil {
+		log.Errorf("Unable to get the invitation - %d. Err: %s", invitationID, err.Error())
+		return nil, fmt.Errorf("The invitation does not exist")
+	}
+
+	return invitation, nil
+}
+
+// GetInvitationByToken returns the invitation with the given token
+func GetInvitationByToken(log logger.MultiLogger, token string) (*Invitation, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	invitation := &Invitation{}
+	err = client.GetPGClient().Model(invitation).
+		Where("token = ?", token).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the invitation for the token. Err: %s", err.Error())
+		return nil, fmt.Errorf("The invitation does not exist")
+	}
+
+	return invitation, nil
+}
+
+// Accept adds the user to the group of the invitation
+// The caller checks that the user is the invitee, email invitations are accepted by the user registering with the token
+func (model *Invitation) Accept(log logger.MultiLogger, userID int64) error {
+	if model.Status != common.InvitationPending.GetStatusID() {
+		return fmt.Errorf("The invitation is already %s", strings.ToLower(common.InvitationStatus(model.Status).GetString()))
+	}
+	if model.IsExpired() {
+		return fmt.Errorf("The invitation has expired")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(model).WherePK().
+			Set("status = ?", common.InvitationAccepted.GetStatusID()).
+			Set("user_id = ?", userID).
+			Set("responded_at = now()").
+			Where("status = ?", common.InvitationPending.GetStatusID()).
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return pg.ErrNoRows
+		}
+
+		userGroupMap := &UserGroupMap{
+			UserID:         userID,
+			GroupID:        model.GroupID,
+			CreatedBy:      model.InvitedBy,
+			Role:           common.GroupRoleContributor.GetRoleID(),
+			WorkflowStatus: common.WorkflowStatusApproved.GetStatusID(),
+		}
+		_, err = tx.Model(userGroupMap).OnConflict("DO NOTHING").Insert()
+		return err
+	})
+	if err == pg.ErrNoRows {
+		return fmt.Errorf("The invitation is no longer pending")
+	}
+	if err != nil {
+		log.Errorf("Unable to accept the invitation - %d. Err: %s", model.InvitationID, err.Error())
+		return fmt.Errorf("Unable to accept the invitation at the moment")
+	}
+
+	logGroupEvent(log, &GroupEvent{
+		GroupID:      model.GroupID,
+		ActorID:      userID,
+		EventType:    common.GroupEventMemberJoined.GetTypeID(),
+		TargetUserID: model.InvitedBy,
+	})
+
+	return nil
+}
+
+// Respond updates the status of a pending invitation, used for declining and revoking the invitations
+func (model *Invitation) Respond(log logger.MultiLogger, status common.InvitationStatus) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("status = ?", status.GetStatusID()).
+		Set("responded_at = now()").
+		Where("status = ?", common.InvitationPending.GetStatusID()).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the status of the invitation - %d. Err: %s", model.InvitationID, err.Error())
+		return fmt.Errorf("Unable to update the invitation at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The invitation is no longer pending")
+	}
+
+	return nil
+}
+
+// GetPendingInvitationsForUser returns the invitations of the user that can still be accepted
+func GetPendingInvitationsForUser(log logger.MultiLogger, userID int64) ([]*InvitationView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var invitations []*InvitationView
+	err = client.GetPGClient().Model(&invitations).
+		ColumnExpr(`"gi".invitation_id, "gi".group_id, "gi".status, "gi".expires_at, "gi".creation_time`).
+		ColumnExpr(`g.group_name`).
+		ColumnExpr(`u.first_name AS invited_by_first_name, u.last_name AS invited_by_last_name`).
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = \"gi\".group_id").
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"gi\".invited_by").
+		Where("\"gi\".user_id = ?", userID).
+		Where("\"gi\".status = ?", common.InvitationPending.GetStatusID()).
+		Where("\"gi\".expires_at > now()").
+		OrderExpr(`"gi".creation_time DESC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the invitations of user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the invitations")
+	}
+
+	return invitations, nil
+}
+
+// GetPendingInvitationCount returns the number of invitations the user can still accept
+func GetPendingInvitationCount(userID int64) (int, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get database client. Err: %s", err.Error())
+	}
+
+	return client.GetPGClient().Model((*Invitation)(nil)).
+		Where("user_id = ?", userID).
+		Where("status = ?", common.InvitationPending.GetStatusID()).
+		Where("expires_at > now()").
+		Count()
+}
+
+// GetInvitationsForGroup returns the invitations sent in the group, pending invitations first
+func GetInvitationsForGroup(log logger.MultiLogger, groupID int64) ([]*InvitationView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var invitations []*InvitationView
+	err = client.GetPGClient().Model(&invitations).
+		ColumnExpr(`"gi".invitation_id, "gi".token, "gi".group_id, "gi".user_id, "gi".email`).
+		ColumnExpr(`"gi".status, "gi".expires_at, "gi".creation_time`).
+		ColumnExpr(`iu.username, iu.first_name, iu.last_name`).
+		ColumnExpr(`u.first_name AS invited_by_first_name, u.last_name AS invited_by_last_name`).
+		Join("LEFT JOIN appuser AS iu").
+		JoinOn("iu.user_id = \"gi\".user_id").
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"gi\".invited_by").
+		Where("\"gi\".group_id = ?", groupID).
+		OrderExpr(`"gi".status ASC, "gi".creation_time DESC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the invitations of group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the invitations of the group")
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// ModerationQueue is the view model for the items of a group pending for approval
+type ModerationQueue struct {
+	GroupID   int64
+	GroupName string
+	Items     []*ItemView
+}
+
+// GetPendingItems returns the uploaded items of the group waiting for the approval of a leader, oldest first
+func GetPendingItems(log logger.MultiLogger, groupID int64) ([]*ItemView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var items []*ItemView
+	err = client.GetPGClient().Model(&items).
+		ColumnExpr(itemViewColumns).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"item\".created_by").
+		Where("\"item\".group_id = ?", groupID).
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPending.GetStatusID()).
+		OrderExpr(`"item".creation_time ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the pending items of group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the items pending for approval")
+	}
+
+	return items, nil
+}
+
+// Approve publishes an item pending for approval to the members of the group
+// actorID is the leader approving the item, the uploader is notified along with the optional reason
+func (model *Item) Approve(log logger.MultiLogger, actorID int64, reason string) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		Where("approval_status = ?", common.ItemApprovalPending.GetStatusID()).
+		Returning("*").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to approve the item (ID: %d). Err: %s", model.ItemID, err.Error())
+		return fmt.Errorf("Unable to approve the item at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The item is not pending for approval")
+	}
+
+	// The item is logged as uploaded once it is visible to the members
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   model.CreatedBy,
+		EventType: common.GroupEventItemUploaded.GetTypeID(),
+		ItemID:    model.ItemID,
+		ItemName:  model.ItemName,
+	})
+
+	if actorID != model.CreatedBy {
+		addNotifications(log, []*Notification{{
+			UserID:           model.CreatedBy,
+			ActorID:          actorID,
+			NotificationType: common.NotificationItemApproved.GetTypeID(),
+			ItemID:           model.ItemID,
+			GroupID:          model.GroupID,
+			Details:          reason,
+		}})
+	}
+
+	return nil
+}
+
+// Reject deletes an item pending for approval, the file of the item is removed by the caller
+// actorID is the leader rejecting the item, the uploader is notified along with the optional reason
+func (model *Item) Reject(log logger.MultiLogger, actorID int64, reason string) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	// The details of the item cascade
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Where("approval_status = ?", common.ItemApprovalPending.GetStatusID()).
+		Returning("*").
+		Delete()
+	if err != nil {
+		log.Errorf("Unable to reject the item (ID: %d). Err: %s", model.ItemID, err.Error())
+		return fmt.Errorf("Unable to reject the item at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The item is not pending for approval")
+	}
+
+	// The item is gone, so the notification is about the group and names the item in its details
+	if actorID != model.CreatedBy {
+		details := model.ItemName
+		if reason != "" {
+			details = fmt.Sprintf("%s: %s", model.ItemName, reason)
+		}
+		addNotifications(log, []*Notification{{
+			UserID:           model.CreatedBy,
+			ActorID:          actorID,
+			NotificationType: common.NotificationItemRejected.GetTypeID(),
+			GroupID:          model.GroupID,
+			Details:          details,
+		}})
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// itemAccessGroupExpr resolves the group through which an item is listed, from the groups given as ?0
+// The primary group of the item is used if present in the groups, a group the item is shared into otherwise
+// NULL is returned if the item is not in any of the groups
+const itemAccessGroupExpr = `CASE WHEN "item".group_id IN (?0) THEN "item".group_id ELSE (` +
+	`SELECT min(s.group_id) FROM itemshares AS s WHERE s.item_id = "item".item_id AND s.group_id IN (?0)) END`
+
+// ItemShare is the model for an item shared into a group other than its primary group
+// The stored file is reused and only the primary group (Items.group_id) is charged for the space
+type ItemShare struct {
+	tableName    struct{}  `sql:"ItemShares,alias:s"`
+	ItemID       int64     `sql:"item_id,pk"`
+	GroupID      int64     `sql:"group_id,pk"`
+	SharedBy     int64     `sql:"shared_by"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// ItemShareView is the display model for the groups an item is shared into
+type ItemShareView struct {
+	tableName    struct{}  `sql:"ItemShares,alias:s"`
+	ItemID       int64     `sql:"item_id"`
+	GroupID      int64     `sql:"group_id"`
+	GroupName    string    `sql:"group_name"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// Add shares the item into the group
+func (model *ItemShare) Add(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).OnConflict("DO NOTHING").Insert()
+	if err != nil {
+		log.Errorf("Unable to share the item - %d into group - %d. Err: %s", model.ItemID, model.GroupID, err.Error())
+		return fmt.Errorf("Unable to share the item at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The item is already shared with the group")
+	}
+
+	return nil
+}
+
+// Remove removes the item from the group it was shared into, the item itself is not deleted
+func (model *ItemShare) Remove(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().Delete()
+	if err != nil {
+		log.Errorf("Unable to remove the share of item - %d from group - %d. Err: %s", model.ItemID, model.GroupID, err.Error())
+		return fmt.Errorf("Unable to remove the share at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The item is not shared with the group")
+	}
+
+	return nil
+}
+
+// GetSharesForItem returns the groups the item is shared into
+func GetSharesForItem(log logger.MultiLogger, itemID int64) ([]*ItemShareView, error) {
+	shares, err := getSharesForItems(log, []int64{itemID})
+	if err != nil {
+		return nil, err
+	}
+
+	return shares[itemID], nil
+}
+
+// getSharesForItems returns the groups each of the items is shared into
+func getSharesForItems(log logger.MultiLogger, itemIDs []int64) (map[int64][]*ItemShareView, error) {
+	sharesByItem := make(map[int64][]*ItemShareView)
+	if len(itemIDs) == 0 {
+		return sharesByItem, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var shares []*ItemShareView
+	err = client.GetPGClient().Model(&shares).
+		ColumnExpr(`"s".item_id, "s".group_id, "s".creation_time, g.group_name`).
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = \"s\".group_id").
+		Where("\"s\".item_id in (?)", pg.Ints(itemIDs)).
+		Order("group_name ASC").
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the shares of the items. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to fetch the groups the item is shared with")
+	}
+
+	for _, share := range shares {
+		sharesByItem[share.ItemID] = append(sharesByItem[share.ItemID], share)
+	}
+
+	return sharesByItem, nil
+}
+
+// GetItemAccessGroup returns the group through which the user can access the item (from the given groups of the user)
+// preferredGroupID is used if the user can access the item through it, the primary group of the item next
+// ok is false if the user does not have access to the item through any of the groups
+func GetItemAccessGroup(log logger.MultiLogger, groups []*GroupKeyVal, item *Item, preferredGroupID int64) (*GroupKeyVal, bool, error) {
+	userGroups := make(map[int64]*GroupKeyVal)
+	for _, group := range groups {
+		userGroups[group.GroupID] = group
+	}
+
+	primaryGroup, present := userGroups[item.GroupID]
+	if present && (preferredGroupID == 0 || preferredGroupID == item.GroupID) {
+		return primaryGroup, true, nil
+	}
+
+	// The groups the item can be accessed through, in the order of preference
+	shares, err := GetSharesForItem(log, item.ItemID)
+	if err != nil {
+		return nil, false, err
+	}
+	itemGroupIDs := []int64{item.GroupID}
+	for _, share := range shares {
+		if share.GroupID == preferredGroupID {
+			itemGroupIDs = append([]int64{share.GroupID}, itemGroupIDs...)
+		} else {
+			itemGroupIDs = append(itemGroupIDs, share.GroupID)
+		}
+	}
+
+	for _, groupID := range itemGroupIDs {
+		if group, present := userGroups[groupID]; present {
+			return group, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// GetShareableGroups returns the groups from the given groups of the user that the item is not in yet
+func GetShareableGroups(groups []*GroupKeyVal, item *ItemWithComments) []*GroupKeyVal {
+	itemGroups := map[int64]bool{
+		item.ItemMeta.GroupID: true,
+	}
+	for _, share := range item.Shares {
+		itemGroups[share.GroupID] = true
+	}
+
+	var shareableGroups []*GroupKeyVal
+	for _, group := range groups {
+		if !itemGroups[group.GroupID] {
+			shareableGroups = append(shareableGroups, group)
+		}
+	}
+
+	return shareableGroups
+}
+
+// UpdateCommentScope updates whether the comments of the item are shared by all the groups or kept per group
+func (model *Item) UpdateCommentScope(log logger.MultiLogger) error {
+	if model.CommentScope != common.CommentScopeShared && model.CommentScope != common.CommentScopeGroup {
+		return fmt.Errorf("Invalid comment setting")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).
+		Column("comment_scope").
+		WherePK().
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the comment setting of the item (ID: %d). Err: %s", model.ItemID, err.Error())
+		return fmt.Errorf("Unable to update the item at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to update the item at the moment")
+	}
+
+	return nil
+}
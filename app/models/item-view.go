@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// ItemViewer is a user who has viewed an item
+type ItemViewer struct {
+	tableName  struct{}  `sql:"ItemViews,alias:v"`
+	UserID     int64     `sql:"user_id"`
+	FirstName  string    `sql:"first_name"`
+	LastName   string    `sql:"last_name"`
+	ViewCount  int       `sql:"view_count"`
+	LastViewed time.Time `sql:"last_viewed"`
+}
+
+// ItemViewStats holds the view count of an item and the users who have viewed it
+type ItemViewStats struct {
+	ViewCount int
+	Viewers   []*ItemViewer
+}
+
+// ItemViewCount is the view count of an item, used for the group analytics
+type ItemViewCount struct {
+	tableName    struct{}  `sql:"Items,alias:item"`
+	ItemID       int64     `sql:"item_id"`
+	ItemName     string    `sql:"item_name"`
+	ItemTypeID   int       `sql:"item_type_id"`
+	CreationTime time.Time `sql:"creation_time"`
+	LastAccessed time.Time `sql:"last_accessed"`
+	ViewCount    int       `sql:"view_count"`
+}
+
+// GroupAnalytics is the view model for the item analytics of a group
+type GroupAnalytics struct {
+	GroupID     int64
+	GroupName   string
+	MostViewed  []*ItemViewCount
+	LeastViewed []*ItemViewCount
+}
+
+// RecordItemView records a view of the item by the user and updates the last accessed time of the item
+// Repeated views by the same user within common.ItemViewDebounceMinutes are counted once
+func RecordItemView(log logger.MultiLogger, itemID, userID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO itemviews (item_id, user_id)
+			SELECT ?0, ?1
+			WHERE NOT EXISTS (
+				SELECT 1 FROM itemviews
+				WHERE item_id = ?0 AND user_id = ?1 AND creation_time > now() - ?2 * interval '1 minute'
+			)`, itemID, userID, common.ItemViewDebounceMinutes)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model((*Item)(nil)).
+			Set("last_accessed = now()").
+			Where("item_id = ?", itemID).
+			Update()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to record the view of item - %d by user - %d. Err: %s", itemID, userID, err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	return nil
+}
+
+// GetItemViewStats returns the view count of the item and the users who have viewed it, latest first
+func GetItemViewStats(log logger.MultiLogger, itemID int64) (*ItemViewStats, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var viewers []*ItemViewer
+	err = client.GetPGClient().Model(&viewers).
+		ColumnExpr(`"v".user_id, u.first_name, u.last_name`).
+		ColumnExpr(`count(*) AS view_count, max("v".creation_time) AS last_viewed`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"v\".user_id").
+		Where("\"v\".item_id = ?", itemID).
+		GroupExpr(`"v".user_id, u.first_name, u.last_name`).
+		OrderExpr(`last_viewed DESC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the viewers of item - %d. Err: %s", itemID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the views of the item")
+	}
+
+	stats := &ItemViewStats{
+		Viewers: viewers,
+	}
+	for _, viewer := range viewers {
+		stats.ViewCount += viewer.ViewCount
+	}
+
+	return stats, nil
+}
+
+// GetGroupAnalytics returns the most viewed and the least viewed items of the group
+func GetGroupAnalytics(log logger.MultiLogger, groupID int64) (*GroupAnalytics, error) {
+	mostViewed, err := getItemViewCounts(log, groupID, "DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	leastViewed, err := getItemViewCounts(log, groupID, "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &GroupAnalytics{
+		GroupID:     groupID,
+		MostViewed:  mostViewed,
+		LeastViewed: leastViewed,
+	}
+
+	return analytics, nil
+}
+
+// getItemViewCounts returns the view counts of the uploaded items of the group in the given order
+// Items that were never viewed are included with a count of 0
+func getItemViewCounts(log logger.MultiLogger, groupID int64, order string) ([]*ItemViewCount, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var items []*ItemViewCount
+	err = client.GetPGClient().Model(&items).
+		ColumnExpr(`"item".item_id, "item".item_name, "item".item_type_id, "item".creation_time, "item".last_accessed`).
+		ColumnExpr(`count(v.view_id) AS view_count`).
+		Join("LEFT JOIN itemviews AS v").
+		JoinOn("v.item_id = \"item\".item_id").
+		Where("\"item\".group_id = ?", groupID).
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		GroupExpr(`"item".item_id`).
+		OrderExpr(fmt.Sprintf(`view_count %s, "item".creation_time DESC`, order)).
+		Limit(common.AnalyticsListSize).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the view counts of the items in group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the analytics of the group")
+	}
+
+	return items, nil
+}
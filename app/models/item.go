@@ -7,12 +7,19 @@ import (
 	"github.com/go-pg/pg"
 
 	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/database"
 )
 
 const (
 	// MB is the const for conversion to MB from bytes (1 MB = 1e6 bytes)
 	MB = 1e-06
+
+	// itemViewColumns are the columns of the Items table mapped in ItemView
+	// (search_vector is only used by the full-text search queries)
+	itemViewColumns = `"item".item_id, "item".item_name, "item".description, "item".item_type_id, ` +
+		`"item".item_size, "item".group_id, "item".uploaded, "item".item_path, "item".created_by, ` +
+		`"item".creation_time, "item".last_accessed, "item".comment_count, "item".comment_scope, "item".approval_status`
 )
 
 // Item is the model for the metadata of an item added by a user
@@ -29,6 +36,9 @@ type Item struct {
 	CreatedBy    int64     `sql:"created_by"`
 	CreationTime time.Time `sql:"creation_time"`
 	LastAccessed time.Time `sql:"last_accessed"`
+	CommentScope string    `sql:"comment_scope"`
+	// ApprovalStatus is pending for the uploads waiting for the approval of a leader of the group
+	ApprovalStatus int `sql:"approval_status"`
 }
 
 // ItemView is the model for the metadata of an item to be used in the view
@@ -47,13 +57,46 @@ type ItemView struct {
 	CreatedBy          int64     `sql:"created_by"`
 	CreationTime       time.Time `sql:"creation_time"`
 	LastAccessed       time.Time `sql:"last_accessed"`
+	CommentCount       int       `sql:"comment_count"`
+	CommentScope       string    `sql:"comment_scope"`
+	ApprovalStatus     int       `sql:"approval_status"`
 }
 
 // ItemWithComments holds the item details with all comments
+// GroupID and GroupName are of the group through which the item is viewed
 type ItemWithComments struct {
-	ItemMeta  *ItemView
+	ItemMeta    *ItemView
+	GroupID     int64
+	GroupName   string
+	Shares      []*ItemShareView
+	Comments    []*CommentDisplay
+	Tags        []*Tag
+	Albums      []*Album
+	GroupAlbums []*AlbumView
+	AlbumNav    *AlbumNavigation
+	Mentions    map[string]string
+	Reactions   *ReactionBar
+	IsFavorite  bool
+	Views       *ItemViewStats
+	// ShareableGroups are the groups the item can be shared into, set for the owner of the item
+	IsOwner         bool
+	ShareableGroups []*GroupKeyVal
+	CanRemoveShare  bool
+	// CanComment, CanEdit and CanDelete are set from the role of the user in the group the item is viewed through
+	CanComment bool
+	CanEdit    bool
+	CanDelete  bool
+	// ShareLinks are set for the users who can manage the public share links of the item
+	ShareLinks *ShareLinkList
+	// CanApprove is set for the leaders of the group when the item is pending for approval
+	CanApprove bool
+}
+
+// ItemEdit is the view model for editing the details of an item
+type ItemEdit struct {
+	ItemMeta  *Item
 	GroupName string
-	Comments  []*CommentDisplay
+	Tags      string
 }
 
 // Add adds the metadata of an item to database
@@ -79,6 +122,31 @@ func (model *Item) Add(log logger.MultiLogger) error {
 	return nil
 }
 
+// UpdateDetails updates the name and description of an item
+func (model *Item) UpdateDetails(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).
+		Column("item_name").
+		Column("description").
+		WherePK().
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the details of the item (ID: %d). Err: %s", model.ItemID, err.Error())
+		return fmt.Errorf("Unable to update the item at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to update the item at the moment")
+	}
+
+	return nil
+}
+
 // MarkItemAsUploaded updates the upload status of the item to true
 func MarkItemAsUploaded(log logger.MultiLogger, itemID int64) error {
 	// Get Database client
@@ -92,7 +160,7 @@ func MarkItemAsUploaded(log logger.MultiLogger, itemID int64) error {
 		ItemID: itemID,
 	}
 
-	res, err := client.GetPGClient().Model(model).WherePK().Set("uploaded = ?", true).Update()
+	res, err := client.GetPGClient().Model(model).WherePK().Set("uploaded = ?", true).Returning("*").Update()
 	if err != nil {
 		log.Errorf("Unable to update the upload status of the item (ID: %d). Err: %s", itemID, err.Error())
 		return fmt.Errorf("Unable to process the request")
@@ -101,32 +169,138 @@ func MarkItemAsUploaded(log logger.MultiLogger, itemID int64) error {
 		return fmt.Errorf("Unable to process the request")
 	}
 
+	// The items pending for approval are logged once they are approved
+	if model.IsPending() {
+		return nil
+	}
+
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   model.CreatedBy,
+		EventType: common.GroupEventItemUploaded.GetTypeID(),
+		ItemID:    model.ItemID,
+		ItemName:  model.ItemName,
+	})
+
 	return nil
 }
 
-// GetItemsByGroupIDs returns all the item objects corresponding to the group ids provided
-func GetItemsByGroupIDs(groupIDs []int64) ([]*Item, error) {
+// IsPending checks whether the item is waiting for the approval of a leader of the group
+func (model *Item) IsPending() bool {
+	return model.ApprovalStatus == common.ItemApprovalPending.GetStatusID()
+}
+
+// IsPending checks whether the item is waiting for the approval of a leader of the group
+func (model *ItemView) IsPending() bool {
+	return model.ApprovalStatus == common.ItemApprovalPending.GetStatusID()
+}
+
+// GetItemsByGroupIDs returns a page of the uploaded items of the groups in the feed query, including the items shared into the groups
+// Items are returned in the sort order of the query, starting after the query's cursor
+func GetItemsByGroupIDs(log logger.MultiLogger, feedQuery *FeedQuery) (*FeedPage, error) {
+	feedPage := &FeedPage{}
+	if len(feedQuery.GroupIDs) == 0 {
+		return feedPage, nil
+	}
+
+	// Decode the cursor of the last item of the previous page
+	cursor, err := decodeFeedCursor(feedQuery.Cursor, feedQuery.Sort)
+	if err != nil {
+		log.Errorf("Invalid feed cursor - %s. Err: %s", feedQuery.Cursor, err.Error())
+		return nil, fmt.Errorf("Invalid page requested")
+	}
+
 	// Get Database client
 	client, err := database.GetClient()
 	if err != nil {
-		return nil, fmt.Errorf("Unable to get database client. Err: %s", err.Error())
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var items []*FeedItem
+	query := client.GetPGClient().Model(&items).
+		ColumnExpr(`"item".item_id, "item".item_name, "item".description, "item".item_type_id`).
+		ColumnExpr(`g.group_id, "item".item_path, "item".creation_time, "item".comment_count`).
+		ColumnExpr(`g.group_name, u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		// Items are listed under their primary group or a group they are shared into,
+		// the items not in any of the groups of the query are left out by the join
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = "+itemAccessGroupExpr, pg.Ints(feedQuery.GroupIDs)).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"item\".created_by").
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID())
+
+	if feedQuery.ItemTypeID > 0 {
+		query = query.Where("\"item\".item_type_id = ?", feedQuery.ItemTypeID)
+	}
+	if feedQuery.UploaderID > 0 {
+		query = query.Where("\"item\".created_by = ?", feedQuery.UploaderID)
+	}
+	if feedQuery.UserID > 0 {
+		// Items and comments added after the user's last seen time of the group are new
+		query = query.ColumnExpr(`"item".creation_time > coalesce(gls.last_seen, '-infinity') AS is_new`).
+			ColumnExpr(`(SELECT count(*) FROM comments AS c WHERE c.item_id = "item".item_id AND NOT c.is_deleted `+
+				`AND c.creation_time > coalesce(gls.last_seen, '-infinity')) AS new_comment_count`).
+			Join("LEFT JOIN grouplastseen AS gls").
+			JoinOn("gls.group_id = g.group_id").
+			JoinOn("gls.user_id = ?", feedQuery.UserID)
+		if feedQuery.UnseenOnly {
+			query = query.Where(`"item".creation_time > coalesce(gls.last_seen, '-infinity')`)
+		}
+	}
+
+	switch feedQuery.Sort {
+	case common.FeedSortOldest:
+		if cursor != nil {
+			query = query.Where("(\"item\".creation_time, \"item\".item_id) > (?, ?)", cursor.CreationTime, cursor.ItemID)
+		}
+		query = query.OrderExpr(`"item".creation_time ASC, "item".item_id ASC`)
+	case common.FeedSortMostCommented:
+		if cursor != nil {
+			query = query.Where("(\"item\".comment_count, \"item\".item_id) < (?, ?)", cursor.CommentCount, cursor.ItemID)
+		}
+		query = query.OrderExpr(`"item".comment_count DESC, "item".item_id DESC`)
+	default:
+		if cursor != nil {
+			query = query.Where("(\"item\".creation_time, \"item\".item_id) < (?, ?)", cursor.CreationTime, cursor.ItemID)
+		}
+		query = query.OrderExpr(`"item".creation_time DESC, "item".item_id DESC`)
+	}
+
+	// Fetch one extra item to find out whether there is a next page
+	err = query.Limit(feedQuery.Limit + 1).Select()
+	if err != nil {
+		log.Errorf("Unable to get the items for the feed. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to fetch the item metadata")
 	}
 
-	// Get all items for the group IDs provided
-	var items []*Item
-	err = client.GetPGClient().Model(&items).
-		Where("group_id in (?)", pg.Ints(groupIDs)).
-		Where("uploaded = ?", true).
-		Select()
+	if len(items) > feedQuery.Limit {
+		items = items[:feedQuery.Limit]
+		feedPage.NextCursor = encodeFeedCursor(items[len(items)-1], feedQuery.Sort)
+	}
+
+	// Get the top reactions of the items on the page
+	itemIDs := make([]int64, len(items))
+	for index, item := range items {
+		itemIDs[index] = item.ItemID
+	}
+	topReactions, err := getTopReactionsForItems(log, itemIDs)
 	if err != nil {
+		// We already logged this error
 		return nil, err
 	}
+	for _, item := range items {
+		item.TopReactions = topReactions[item.ItemID]
+	}
+	feedPage.Items = items
 
-	return items, nil
+	return feedPage, nil
 }
 
 // GetItemDetailsWithItemID returns the metadata of the item along with comments
-func GetItemDetailsWithItemID(log logger.MultiLogger, itemID int64) (*ItemWithComments, error) {
+// groupID is the group through which the item is viewed, used for the comments kept per group
+func GetItemDetailsWithItemID(log logger.MultiLogger, itemID, groupID int64) (*ItemWithComments, error) {
 	// Get Database client
 	client, err := database.GetClient()
 	if err != nil {
@@ -140,7 +314,7 @@ func GetItemDetailsWithItemID(log logger.MultiLogger, itemID int64) (*ItemWithCo
 
 	// Get Item metadata
 	err = client.GetPGClient().Model(itemMeta).WherePK().
-		ColumnExpr(`"item".*`).
+		ColumnExpr(itemViewColumns).
 		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
 		Join("JOIN appuser AS u").
 		JoinOn("u.user_id = \"item\".created_by").
@@ -151,15 +325,63 @@ func GetItemDetailsWithItemID(log logger.MultiLogger, itemID int64) (*ItemWithCo
 	}
 
 	// Get all the comments
-	comments, err := GetCommentsForAnItem(log, itemID)
+	commentGroupID := int64(0)
+	if itemMeta.CommentScope == common.CommentScopeGroup {
+		commentGroupID = groupID
+	}
+	comments, err := GetCommentsForAnItem(log, itemID, commentGroupID)
+	if err != nil {
+		// error is already logged
+		return nil, err
+	}
+
+	// Get the groups the item is shared into
+	shares, err := GetSharesForItem(log, itemID)
+	if err != nil {
+		// error is already logged
+		return nil, err
+	}
+
+	// Get all the tags
+	tags, err := GetTagsForItem(log, itemID)
+	if err != nil {
+		// error is already logged
+		return nil, err
+	}
+
+	// Get all the albums containing the item
+	albums, err := GetAlbumsForItem(log, itemID)
+	if err != nil {
+		// error is already logged
+		return nil, err
+	}
+
+	// Resolve the mentions in the description and the comments, in the group the text was posted in
+	mentions, err := GetMentionLinks(log, itemMeta.GroupID, []string{itemMeta.Description})
 	if err != nil {
 		// error is already logged
 		return nil, err
 	}
+	commentTexts := make(map[int64][]string)
+	getCommentTexts(comments, itemMeta.GroupID, commentTexts)
+	commentMentions := make(map[int64]map[string]string)
+	for commentGroupID, texts := range commentTexts {
+		commentMentions[commentGroupID], err = GetMentionLinks(log, commentGroupID, texts)
+		if err != nil {
+			// error is already logged
+			return nil, err
+		}
+	}
+	setCommentMentions(comments, itemMeta.GroupID, commentMentions)
 
 	itemWithComments := &ItemWithComments{
 		ItemMeta: itemMeta,
+		GroupID:  groupID,
+		Shares:   shares,
 		Comments: comments,
+		Tags:     tags,
+		Albums:   albums,
+		Mentions: mentions,
 	}
 
 	return itemWithComments, nil
@@ -222,28 +444,52 @@ func (model *Item) checkPerUserLimits(log logger.MultiLogger, fileSizeInMB float
 	return nil
 }
 
+// checkPerGroupLimit checks the limits of the group and of its ancestor groups
+// The usage of a group includes the items of its subgroups
 func (model *Item) checkPerGroupLimit(log logger.MultiLogger, fileSizeInMB float32) error {
-	// Get the limits tagged to the user
+	// Get the limits tagged to the group
 	group, err := GetGroupDetailUsingID(model.GroupID)
 	if err != nil {
-		log.Errorf("Unable to get upload limits for the user. Error: %s", err.Error())
-		return fmt.Errorf("Unable to fetch upload limits for the user")
-	}
-
-	// Get the aggregated count of items uploaded and space utilized by the user
-	count, size, err := getLimitUtilizationForGroup(model.GroupID)
-	log.Infof("[Group Limits] Count = %d, Size = %f, Uploaded file size = %f MB", count, size, fileSizeInMB)
-	if err != nil {
-		log.Errorf(err.Error())
-		return fmt.Errorf("Unable to fetch upload limits for the user")
-	}
-
-	if !(count < group.MaxItemCount) {
-		return fmt.Errorf("Only %d items can be uploaded in the group", group.MaxItemCount)
-	}
-
-	if !(size+fileSizeInMB < group.MaxItemSpace) {
-		return fmt.Errorf("The group is limited to %.3f MB of space for uploads", group.MaxItemSpace)
+		log.Errorf("Unable to get upload limits for the group. Error: %s", err.Error())
+		return fmt.Errorf("Unable to fetch upload limits for the group")
+	}
+
+	checked := make(map[int64]bool)
+	for !checked[group.GroupID] {
+		checked[group.GroupID] = true
+
+		// Get the aggregated count of items uploaded and space utilized in the group and its subgroups
+		count, size, err := getLimitUtilizationForGroup(group.GroupID)
+		log.Infof("[Group Limits] Group = %d, Count = %d, Size = %f, Uploaded file size = %f MB", group.GroupID, count, size, fileSizeInMB)
+		if err != nil {
+			log.Errorf(err.Error())
+			return fmt.Errorf("Unable to fetch upload limits for the group")
+		}
+
+		if group.GroupID == model.GroupID {
+			if !(count < group.MaxItemCount) {
+				return fmt.Errorf("Only %d items can be uploaded in the group", group.MaxItemCount)
+			}
+			if !(size+fileSizeInMB < group.MaxItemSpace) {
+				return fmt.Errorf("The group is limited to %.3f MB of space for uploads", group.MaxItemSpace)
+			}
+		} else {
+			if !(count < group.MaxItemCount) {
+				return fmt.Errorf("Only %d items can be uploaded in the parent group '%s' and its subgroups", group.MaxItemCount, group.GroupName)
+			}
+			if !(size+fileSizeInMB < group.MaxItemSpace) {
+				return fmt.Errorf("The parent group '%s' and its subgroups are limited to %.3f MB of space for uploads", group.GroupName, group.MaxItemSpace)
+			}
+		}
+
+		if group.ParentGroupID == 0 {
+			break
+		}
+		group, err = GetGroupDetailUsingID(group.ParentGroupID)
+		if err != nil {
+			log.Errorf("Unable to get upload limits for the parent group. Error: %s", err.Error())
+			return fmt.Errorf("Unable to fetch upload limits for the group")
+		}
 	}
 
 	return nil
@@ -294,6 +540,7 @@ func getLimitUtilizationForUser(userID int64) (int, float32, error) {
 	return count, totalSize * MB, nil
 }
 
+// getLimitUtilizationForGroup returns the count and the size of the items in the group and all its subgroups
 func getLimitUtilizationForGroup(groupID int64) (int, float32, error) {
 	var count int
 	var totalSize float32
@@ -304,10 +551,15 @@ func getLimitUtilizationForGroup(groupID int64) (int, float32, error) {
 		return -1, -1, fmt.Errorf("Unable to get database client. Err: %s", err.Error())
 	}
 
-	err = client.GetPGClient().Model((*Item)(nil)).
-		ColumnExpr("count(*) AS count, sum(item_size) AS totalSize").
-		Where("group_id = ?", groupID).
-		Select(&count, &totalSize)
+	_, err = client.GetPGClient().QueryOne(pg.Scan(&count, &totalSize), `
+		WITH RECURSIVE subtree AS (
+			SELECT g.group_id FROM groups AS g WHERE g.group_id = ?
+			UNION
+			SELECT g.group_id FROM groups AS g
+			JOIN subtree AS s ON g.parent_group_id = s.group_id
+		)
+		SELECT count(*) AS count, coalesce(sum(i.item_size), 0) AS totalSize FROM items AS i
+		WHERE i.group_id IN (SELECT s.group_id FROM subtree AS s)`, groupID)
 
 	if err != nil {
 		return -1, -1, fmt.Errorf("Unable to get the utilized limits for the group - %d. Error: %s", groupID, err.Error())
@@ -363,7 +615,8 @@ func GetItemDetailsByID(log logger.MultiLogger, itemID int64) (*Item, error) {
 }
 
 // Delete deletes the metadata of an item from database
-func (model *Item) Delete(log logger.MultiLogger) error {
+// actorID is the user deleting the item
+func (model *Item) Delete(log logger.MultiLogger, actorID int64) error {
 	// Get Database client
 	client, err := database.GetClient()
 	if err != nil {
@@ -382,6 +635,14 @@ func (model *Item) Delete(log logger.MultiLogger) error {
 		return fmt.Errorf("Unable to delete the item at the moment")
 	}
 
+	logGroupEvent(log, &GroupEvent{
+		GroupID:   model.GroupID,
+		ActorID:   actorID,
+		EventType: common.GroupEventItemDeleted.GetTypeID(),
+		ItemID:    model.ItemID,
+		ItemName:  model.ItemName,
+	})
+
 	return nil
 }
 
@@ -0,0 +1,330 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// JoinRequest is the model for the requests of the users to join a group
+// The requests are handled by the leaders of the group
+type JoinRequest struct {
+	tableName      struct{}  `sql:"GroupJoinRequests,alias:jr"`
+	RequestID      int64     `sql:"request_id,pk"`
+	GroupID        int64     `sql:"group_id"`
+	UserID         int64     `sql:"user_id"`
+	Message        string    `sql:"message"`
+	WorkflowStatus int       `sql:"workflow_status"`
+	HandledBy      int64     `sql:"handled_by"`
+	CreationTime   time.Time `sql:"creation_time"`
+	HandledAt      time.Time `sql:"handled_at"`
+}
+
+// JoinRequestView is the display model for the requests to join a group
+type JoinRequestView struct {
+	tableName          struct{}  `sql:"GroupJoinRequests,alias:jr"`
+	RequestID          int64     `sql:"request_id,pk"`
+	GroupID            int64     `sql:"group_id"`
+	GroupName          string    `sql:"group_name"`
+	UserID             int64     `sql:"user_id"`
+	Username           string    `sql:"username"`
+	FirstName          string    `sql:"first_name"`
+	LastName           string    `sql:"last_name"`
+	Message            string    `sql:"message"`
+	WorkflowStatus     int       `sql:"workflow_status"`
+	HandledByFirstName string    `sql:"handled_by_first_name"`
+	HandledByLastName  string    `sql:"handled_by_last_name"`
+	CreationTime       time.Time `sql:"creation_time"`
+	HandledAt          time.Time `sql:"handled_at"`
+}
+
+// JoinableGroup is the display model for the groups a user can request to join
+type JoinableGroup struct {
+	tableName          struct{}  `sql:"Groups,alias:group"`
+	GroupID            int64     `sql:"group_id,pk"`
+	GroupName          string    `sql:"group_name"`
+	CreatedByFirstName string    `sql:"created_by_first_name"`
+	CreatedByLastName  string    `sql:"created_by_last_name"`
+	CreationTime       time.Time `sql:"creation_time"`
+	MemberCount        int       `sql:"member_count"`
+	HasPendingRequest  bool      `sql:"has_pending_request"`
+}
+
+// JoinRequestList is the view model for browsing the groups to join
+type JoinRequestList struct {
+	Groups   []*JoinableGroup
+	Requests []*JoinRequestView
+}
+
+// GetJoinableGroups returns the approved groups the user is not a member of, the hidden groups are not listed
+func GetJoinableGroups(log logger.MultiLogger, userID int64) ([]*JoinableGroup, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var groups []*JoinableGroup
+	err = client.GetPGClient().Model(&groups).
+		ColumnExpr(`"group".group_id, "group".group_name, "group".creation_time`).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		ColumnExpr(`(SELECT count(*) FROM usergroupmap AS ugm WHERE ugm.group_id = "group".group_id) AS member_count`).
+		ColumnExpr(`EXISTS (SELECT 1 FROM groupjoinrequests AS jr WHERE jr.group_id = "group".group_id AND jr.user_id = ?0 AND jr.workflow_status = ?1) AS has_pending_request`,
+			userID, common.WorkflowStatusPending.GetStatusID()).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"group\".created_by").
+		Where("\"group\".workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+		Where("NOT EXISTS (SELECT 1 FROM usergroupmap AS ugm WHERE ugm.group_id = \"group\".group_id AND ugm.user_id = ?)", userID).
+		Where("\"group\".visibility <> ?", common.GroupVisibilityHidden.GetVisibilityID()).
+		OrderExpr(`"group".group_name ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the joinable groups for user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the groups")
+	}
+
+	return groups, nil
+}
+
+// Add creates the request to join the group and notifies the leaders of the group
+func (model *JoinRequest) Add(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	group := &Group{
+		GroupID: model.GroupID,
+	}
+	err = client.GetPGClient().Select(group)
+	// The hidden groups are joined by invitation only
+	if err != nil || group.WorkflowStatus != common.WorkflowStatusApproved.GetStatusID() ||
+		group.Visibility == common.GroupVisibilityHidden.GetVisibilityID() {
+		return fmt.Errorf("The group does not exist")
+	}
+
+	count, err := client.GetPGClient().Model((*UserGroupMap)(nil)).
+		Where("user_id = ?", model.UserID).
+		Where("group_id = ?", model.GroupID).
+		Count()
+	if err != nil {
+		log.Errorf("Unable to check the membership of user - %d in group - %d. Err: %s", model.UserID, model.GroupID, err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+	if count > 0 {
+		return fmt.Errorf("You are already a member of the group")
+	}
+
+	model.WorkflowStatus = common.WorkflowStatusPending.GetStatusID()
+	res, err := client.GetPGClient().Model(model).OnConflict("DO NOTHING").Returning("*").Insert()
+	if err != nil {
+		log.Errorf("Unable to insert the join request into database. Err: %s", err.Error())
+		return fmt.Errorf("Unable to send the request at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("You have already requested to join the group '%s'", group.GroupName)
+	}
+
+	leaderIDs, err := getGroupLeaderIDs(log, model.GroupID)
+	if err != nil {
+		// error is already logged, the request is sent regardless
+		return nil
+	}
+
+	var notifications []*Notification
+	for _, leaderID := range leaderIDs {
+		notifications = append(notifications, &Notification{
+			UserID:           leaderID,
+			ActorID:          model.UserID,
+			NotificationType: common.NotificationJoinRequestReceived.GetTypeID(),
+			GroupID:          model.GroupID,
+		})
+	}
+	addNotifications(log, notifications)
+
+	return nil
+}
+
+// GetJoinRequestByID returns the join request with the given ID
+func GetJoinRequestByID(log logger.MultiLogger, requestID int64) (*JoinRequest, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	request := &JoinRequest{
+		RequestID: requestID,
+	}
+	err = client.GetPGClient().Select(request)
+	if err != nil {
+		log.Errorf("Unable to get the join request - %d. Err: %s", requestID, err.Error())
+		return nil, fmt.Errorf("The join request does not exist")
+	}
+
+	return request, nil
+}
+
+// ApproveOrReject is used by a leader of the group to handle the join request
+// The user is added to the group on approval, and notified either way
+func (model *JoinRequest) ApproveOrReject(log logger.MultiLogger, approve bool, leaderID int64) error {
+	// Note: Authz check is already done at this point
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	status := common.WorkflowStatusRejected
+	notificationType := common.NotificationJoinRequestRejected
+	if approve {
+		status = common.WorkflowStatusApproved
+		notificationType = common.NotificationJoinRequestApproved
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(model).WherePK().
+			Set("workflow_status = ?", status.GetStatusID()).
+			Set("handled_by = ?", leaderID).
+			Set("handled_at = now()").
+			Where("workflow_status = ?", common.WorkflowStatusPending.GetStatusID()).
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return pg.ErrNoRows
+		}
+
+		if !approve {
+			return nil
+		}
+
+		userGroupMap := &UserGroupMap{
+			UserID:         model.UserID,
+			GroupID:        model.GroupID,
+			CreatedBy:      leaderID,
+			Role:           common.GroupRoleContributor.GetRoleID(),
+			WorkflowStatus: common.WorkflowStatusApproved.GetStatusID(),
+		}
+		_, err = tx.Model(userGroupMap).OnConflict("DO NOTHING").Insert()
+		return err
+	})
+	if err == pg.ErrNoRows {
+		return fmt.Errorf("The join request is already handled")
+	}
+	if err != nil {
+		log.Errorf("Unable to handle the join request - %d. Err: %s", model.RequestID, err.Error())
+		return fmt.Errorf("Unable to perform the action at the moment")
+	}
+
+	if approve {
+		logGroupEvent(log, &GroupEvent{
+			GroupID:      model.GroupID,
+			ActorID:      leaderID,
+			EventType:    common.GroupEventMemberAdded.GetTypeID(),
+			TargetUserID: model.UserID,
+		})
+	}
+
+	addNotifications(log, []*Notification{
+		{
+			UserID:           model.UserID,
+			ActorID:          leaderID,
+			NotificationType: notificationType.GetTypeID(),
+			GroupID:          model.GroupID,
+		},
+	})
+
+	return nil
+}
+
+// GetPendingJoinRequestsForGroup returns the join requests of the group waiting for a leader, oldest first
+func GetPendingJoinRequestsForGroup(log logger.MultiLogger, groupID int64) ([]*JoinRequestView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var requests []*JoinRequestView
+	err = client.GetPGClient().Model(&requests).
+		ColumnExpr(`"jr".request_id, "jr".group_id, "jr".user_id, "jr".message, "jr".workflow_status, "jr".creation_time`).
+		ColumnExpr(`u.username, u.first_name, u.last_name`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"jr\".user_id").
+		Where("\"jr\".group_id = ?", groupID).
+		Where("\"jr\".workflow_status = ?", common.WorkflowStatusPending.GetStatusID()).
+		OrderExpr(`"jr".creation_time ASC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the join requests of group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the join requests of the group")
+	}
+
+	return requests, nil
+}
+
+// GetJoinRequestsForUser returns the join requests sent by the user, latest first
+func GetJoinRequestsForUser(log logger.MultiLogger, userID int64) ([]*JoinRequestView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var requests []*JoinRequestView
+	err = client.GetPGClient().Model(&requests).
+		ColumnExpr(`"jr".request_id, "jr".group_id, "jr".message, "jr".workflow_status, "jr".creation_time, "jr".handled_at`).
+		ColumnExpr(`g.group_name`).
+		ColumnExpr(`u.first_name AS handled_by_first_name, u.last_name AS handled_by_last_name`).
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = \"jr\".group_id").
+		Join("LEFT JOIN appuser AS u").
+		JoinOn("u.user_id = \"jr\".handled_by").
+		Where("\"jr\".user_id = ?", userID).
+		OrderExpr(`"jr".creation_time DESC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the join requests of user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch your join requests")
+	}
+
+	return requests, nil
+}
+
+// getGroupLeaderIDs returns the user IDs of the approved leaders of the group
+func getGroupLeaderIDs(log logger.MultiLogger, groupID int64) ([]int64, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var leaderIDs []int64
+	err = client.GetPGClient().Model((*UserGroupMap)(nil)).
+		Column("user_id").
+		Where("group_id = ?", groupID).
+		Where("role = ?", common.GroupRoleLeader.GetRoleID()).
+		Where("workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+		Select(&leaderIDs)
+	if err != nil {
+		log.Errorf("Unable to get the leaders of group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	return leaderIDs, nil
+}
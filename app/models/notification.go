@@ -0,0 +1,305 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// Notification is the model for the notifications sent to the users
+type Notification struct {
+	tableName        struct{}  `sql:"Notifications,alias:n"`
+	NotificationID   int64     `sql:"notification_id,pk"`
+	UserID           int64     `sql:"user_id"`
+	ActorID          int64     `sql:"actor_id"`
+	NotificationType int       `sql:"notification_type"`
+	ItemID           int64     `sql:"item_id"`
+	CommentID        int64     `sql:"comment_id"`
+	GroupID          int64     `sql:"group_id"`
+	Details          string    `sql:"details"`
+	IsRead           bool      `sql:"is_read,default:false"`
+	CreationTime     time.Time `sql:"creation_time"`
+}
+
+// NotificationView is the display model for the notifications
+type NotificationView struct {
+	tableName        struct{}  `sql:"Notifications,alias:n"`
+	NotificationID   int64     `sql:"notification_id,pk"`
+	NotificationType int       `sql:"notification_type"`
+	ItemID           int64     `sql:"item_id"`
+	ItemName         string    `sql:"item_name"`
+	CommentID        int64     `sql:"comment_id"`
+	GroupID          int64     `sql:"group_id"`
+	GroupName        string    `sql:"group_name"`
+	Details          string    `sql:"details"`
+	ActorFirstName   string    `sql:"actor_first_name"`
+	ActorLastName    string    `sql:"actor_last_name"`
+	IsRead           bool      `sql:"is_read"`
+	CreationTime     time.Time `sql:"creation_time"`
+}
+
+// GetMessage returns the text of the notification
+// The notifications without an item are about a group, Details is appended when present
+func (model *NotificationView) GetMessage() string {
+	name := model.ItemName
+	if model.ItemID == 0 {
+		name = model.GroupName
+	}
+	message := fmt.Sprintf("%s %s %s %s", model.ActorFirstName, model.ActorLastName,
+		common.NotificationType(model.NotificationType).GetString(), name)
+	if model.Details != "" {
+		message = fmt.Sprintf("%s - %s", message, model.Details)
+	}
+	return message
+}
+
+// GetLink returns the link to the content of the notification
+func (model *Notification) GetLink() string {
+	switch common.NotificationType(model.NotificationType) {
+	case common.NotificationJoinRequestReceived:
+		return fmt.Sprintf("/groups/%d#join-requests", model.GroupID)
+	case common.NotificationJoinRequestApproved:
+		return fmt.Sprintf("/groups/%d", model.GroupID)
+	case common.NotificationJoinRequestRejected:
+		return "/joinrequests"
+	case common.NotificationItemRejected:
+		return fmt.Sprintf("/groups/%d", model.GroupID)
+	}
+
+	if model.CommentID > 0 {
+		return fmt.Sprintf("/item/%d#comment-%d", model.ItemID, model.CommentID)
+	}
+
+	return fmt.Sprintf("/item/%d", model.ItemID)
+}
+
+// ResolveMentions returns the user IDs of the mentioned usernames who are members of the group, as in GetAllGroupsKeyVal
+// Usernames of non-members are left out
+func ResolveMentions(log logger.MultiLogger, groupID int64, usernames []string) (map[string]int64, error) {
+	mentions := make(map[string]int64)
+	if len(usernames) == 0 {
+		return mentions, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var users []*User
+	err = client.GetPGClient().Model(&users).
+		ColumnExpr(`"usr".user_id, "usr".username`).
+		Join("JOIN usergroupmap AS ugm").
+		JoinOn("ugm.user_id = \"usr\".user_id").
+		Where("ugm.group_id = ?", groupID).
+		Where("lower(\"usr\".username) in (?)", pg.Strings(usernames)).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to resolve the mentions in group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to process the mentions")
+	}
+
+	for _, user := range users {
+		mentions[strings.ToLower(user.Username)] = user.UserID
+	}
+
+	return mentions, nil
+}
+
+// GetMentionLinks returns the links of the group members mentioned in the texts, keyed by lower-cased username
+func GetMentionLinks(log logger.MultiLogger, groupID int64, texts []string) (map[string]string, error) {
+	var usernames []string
+	for _, text := range texts {
+		usernames = append(usernames, common.ExtractMentions(text)...)
+	}
+
+	mentions, err := ResolveMentions(log, groupID, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make(map[string]string)
+	for username := range mentions {
+		links[username] = fmt.Sprintf("/groups/%d#user-%s", groupID, username)
+	}
+
+	return links, nil
+}
+
+// NotifyMentions notifies the members of the group mentioned in the text, groupID is the group the text was posted in
+// Users already mentioned in the previous version of the text and the actor are not notified again
+func NotifyMentions(log logger.MultiLogger, actorID int64, item *Item, groupID, commentID int64,
+	notificationType common.NotificationType, text, previousText string) error {
+	previousMentions := make(map[string]bool)
+	for _, username := range common.ExtractMentions(previousText) {
+		previousMentions[username] = true
+	}
+
+	var usernames []string
+	for _, username := range common.ExtractMentions(text) {
+		if !previousMentions[username] {
+			usernames = append(usernames, username)
+		}
+	}
+
+	mentions, err := ResolveMentions(log, groupID, usernames)
+	if err != nil {
+		return err
+	}
+
+	var notifications []*Notification
+	for _, userID := range mentions {
+		if userID == actorID {
+			continue
+		}
+
+		notifications = append(notifications, &Notification{
+			UserID:           userID,
+			ActorID:          actorID,
+			NotificationType: notificationType.GetTypeID(),
+			ItemID:           item.ItemID,
+			CommentID:        commentID,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	_, err = client.GetPGClient().Model(&notifications).Insert()
+	if err != nil {
+		log.Errorf("Unable to insert the notifications for item - %d. Err: %s", item.ItemID, err.Error())
+		return fmt.Errorf("Unable to notify the mentioned users")
+	}
+
+	return nil
+}
+
+// addNotifications sends the notifications about the groups
+// The action the notifications are about has already taken place, so failures are only logged
+func addNotifications(log logger.MultiLogger, notifications []*Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return
+	}
+
+	_, err = client.GetPGClient().Model(&notifications).Insert()
+	if err != nil {
+		log.Errorf("Unable to insert the notifications. Err: %s", err.Error())
+	}
+}
+
+// GetNotificationsForUser returns the latest notifications of the user
+func GetNotificationsForUser(log logger.MultiLogger, userID int64) ([]*NotificationView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var notifications []*NotificationView
+	err = client.GetPGClient().Model(&notifications).
+		ColumnExpr(`"n".notification_id, "n".notification_type, "n".item_id, "n".comment_id, "n".group_id`).
+		ColumnExpr(`"n".details, "n".is_read, "n".creation_time, i.item_name, g.group_name`).
+		ColumnExpr(`u.first_name AS actor_first_name, u.last_name AS actor_last_name`).
+		Join("LEFT JOIN items AS i").
+		JoinOn("i.item_id = \"n\".item_id").
+		Join("LEFT JOIN groups AS g").
+		JoinOn("g.group_id = \"n\".group_id").
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"n\".actor_id").
+		Where("\"n\".user_id = ?", userID).
+		OrderExpr(`"n".creation_time DESC`).
+		Limit(common.NotificationsPageSize).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the notifications of user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the notifications")
+	}
+
+	return notifications, nil
+}
+
+// GetUnreadNotificationCount returns the number of unread notifications of the user
+func GetUnreadNotificationCount(userID int64) (int, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get database client. Err: %s", err.Error())
+	}
+
+	return client.GetPGClient().Model((*Notification)(nil)).
+		Where("user_id = ?", userID).
+		Where("is_read = ?", false).
+		Count()
+}
+
+// MarkNotificationAsRead marks the notification of the user as read and returns it
+func MarkNotificationAsRead(log logger.MultiLogger, userID, notificationID int64) (*Notification, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	notification := &Notification{}
+	res, err := client.GetPGClient().Model(notification).
+		Set("is_read = ?", true).
+		Where("notification_id = ?", notificationID).
+		Where("user_id = ?", userID).
+		Returning("*").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to mark the notification - %d as read. Err: %s", notificationID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	if res.RowsAffected() < 1 {
+		return nil, fmt.Errorf("The notification does not exist")
+	}
+
+	return notification, nil
+}
+
+// MarkAllNotificationsAsRead marks all the notifications of the user as read
+func MarkAllNotificationsAsRead(log logger.MultiLogger, userID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	_, err = client.GetPGClient().Model((*Notification)(nil)).
+		Set("is_read = ?", true).
+		Where("user_id = ?", userID).
+		Where("is_read = ?", false).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to mark the notifications of user - %d as read. Err: %s", userID, err.Error())
+		return fmt.Errorf("Unable to update the notifications at the moment")
+	}
+
+	return nil
+}
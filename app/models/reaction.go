@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// Reaction is the model for the emoji reactions of the users on items and comments
+// CommentID is set for the reactions on a comment of the item
+type Reaction struct {
+	tableName    struct{}  `sql:"Reactions,alias:r"`
+	ReactionID   int64     `sql:"reaction_id,pk"`
+	ItemID       int64     `sql:"item_id"`
+	CommentID    int64     `sql:"comment_id"`
+	UserID       int64     `sql:"user_id"`
+	Emoji        string    `sql:"emoji"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// ReactionSummary holds the aggregated count of an emoji on an item or a comment
+type ReactionSummary struct {
+	tableName   struct{} `sql:"Reactions,alias:r"`
+	ItemID      int64    `sql:"item_id" json:"-"`
+	CommentID   int64    `sql:"comment_id" json:"-"`
+	Emoji       string   `sql:"emoji" json:"emoji"`
+	Count       int      `sql:"reaction_count" json:"count"`
+	Users       []string `sql:"users,array" json:"-"`
+	ReactedByMe bool     `sql:"reacted_by_me" json:"-"`
+}
+
+// ReactionBar holds the reactions of an item (CommentID is 0) or a comment, padded with all the allowed reactions
+type ReactionBar struct {
+	ItemID    int64
+	CommentID int64
+	Reactions []*ReactionSummary
+}
+
+// GetUserNames returns the names of the users who reacted
+func (model *ReactionSummary) GetUserNames() string {
+	return strings.Join(model.Users, ", ")
+}
+
+// ToggleReaction adds the reaction of the user, or removes it if the user has already reacted with the emoji
+func (model *Reaction) ToggleReaction(log logger.MultiLogger) error {
+	if !common.IsValidReaction(model.Emoji) {
+		return fmt.Errorf("Invalid reaction")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		query := tx.Model((*Reaction)(nil)).
+			Where("item_id = ?", model.ItemID).
+			Where("user_id = ?", model.UserID).
+			Where("emoji = ?", model.Emoji)
+		if model.CommentID > 0 {
+			query = query.Where("comment_id = ?", model.CommentID)
+		} else {
+			query = query.Where("comment_id IS NULL")
+		}
+
+		res, err := query.Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() > 0 {
+			return nil
+		}
+
+		conflict := "(item_id, user_id, emoji) WHERE comment_id IS NULL DO NOTHING"
+		if model.CommentID > 0 {
+			conflict = "(comment_id, user_id, emoji) WHERE comment_id IS NOT NULL DO NOTHING"
+		}
+		_, err = tx.Model(model).OnConflict(conflict).Insert()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to update the reaction on item - %d (comment - %d). Err: %s", model.ItemID, model.CommentID, err.Error())
+		return fmt.Errorf("Unable to update the reaction at the moment")
+	}
+
+	return nil
+}
+
+// SetReactions sets the reaction bars of the item and its comments for the given user
+func SetReactions(log logger.MultiLogger, itemWithComments *ItemWithComments, userID int64) error {
+	itemID := itemWithComments.ItemMeta.ItemID
+	reactions, err := getReactionsForItem(log, itemID, userID)
+	if err != nil {
+		// We already logged this error
+		return err
+	}
+
+	// Group the reactions by comment (0 for the item itself)
+	reactionsByComment := make(map[int64][]*ReactionSummary)
+	for _, reaction := range reactions {
+		reactionsByComment[reaction.CommentID] = append(reactionsByComment[reaction.CommentID], reaction)
+	}
+
+	itemWithComments.Reactions = &ReactionBar{
+		ItemID:    itemID,
+		Reactions: GetReactionOptions(reactionsByComment[0]),
+	}
+	setCommentReactions(itemWithComments.Comments, reactionsByComment)
+
+	return nil
+}
+
+// setCommentReactions sets the reaction bars on all the comments in the threads, except the deleted ones
+func setCommentReactions(comments []*CommentDisplay, reactionsByComment map[int64][]*ReactionSummary) {
+	for _, comment := range comments {
+		if !comment.IsDeleted {
+			comment.Reactions = &ReactionBar{
+				ItemID:    comment.ItemID,
+				CommentID: comment.CommentID,
+				Reactions: GetReactionOptions(reactionsByComment[comment.CommentID]),
+			}
+		}
+		setCommentReactions(comment.Replies, reactionsByComment)
+	}
+}
+
+// getReactionsForItem returns the reactions on the item and its comments aggregated by emoji
+// ReactedByMe is set for the emojis the given user reacted with
+func getReactionsForItem(log logger.MultiLogger, itemID, userID int64) ([]*ReactionSummary, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var reactions []*ReactionSummary
+	err = client.GetPGClient().Model(&reactions).
+		ColumnExpr(`"r".item_id, coalesce("r".comment_id, 0) AS comment_id, "r".emoji, count(*) AS reaction_count`).
+		ColumnExpr(`array_agg(u.first_name || ' ' || u.last_name ORDER BY "r".creation_time) AS users`).
+		ColumnExpr(`bool_or("r".user_id = ?) AS reacted_by_me`, userID).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"r\".user_id").
+		Where("\"r\".item_id = ?", itemID).
+		GroupExpr(`"r".item_id, "r".comment_id, "r".emoji`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the reactions on item - %d. Err: %s", itemID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the reactions")
+	}
+
+	return reactions, nil
+}
+
+// getTopReactionsForItems returns the most used reactions on each of the items (excluding the comments)
+func getTopReactionsForItems(log logger.MultiLogger, itemIDs []int64) (map[int64][]*ReactionSummary, error) {
+	topReactions := make(map[int64][]*ReactionSummary)
+	if len(itemIDs) == 0 {
+		return topReactions, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var reactions []*ReactionSummary
+	_, err = client.GetPGClient().Query(&reactions, `
+		SELECT t.item_id, t.emoji, t.reaction_count FROM (
+			SELECT item_id, emoji, count(*) AS reaction_count,
+				row_number() OVER (PARTITION BY item_id ORDER BY count(*) DESC, min(creation_time)) AS reaction_rank
+			FROM reactions
+			WHERE item_id IN (?) AND comment_id IS NULL
+			GROUP BY item_id, emoji
+		) AS t WHERE t.reaction_rank <= ?
+		ORDER BY t.item_id, t.reaction_rank`, pg.In(itemIDs), common.FeedTopReactions)
+	if err != nil {
+		log.Errorf("Unable to get the top reactions of the items. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to fetch the reactions")
+	}
+
+	for _, reaction := range reactions {
+		topReactions[reaction.ItemID] = append(topReactions[reaction.ItemID], reaction)
+	}
+
+	return topReactions, nil
+}
+
+// GetReactionOptions returns the reactions of a target padded with all the allowed reactions, in the configured order
+func GetReactionOptions(reactions []*ReactionSummary) []*ReactionSummary {
+	options := make([]*ReactionSummary, len(common.Reactions))
+	for index, emoji := range common.Reactions {
+		options[index] = &ReactionSummary{Emoji: emoji}
+	}
+
+	for _, reaction := range reactions {
+		if order := common.GetReactionOrder(reaction.Emoji); order >= 0 {
+			options[order] = reaction
+		}
+	}
+
+	return options
+}
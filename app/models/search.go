@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// SearchFilter holds the search query along with the filters applied on the results
+type SearchFilter struct {
+	Query      string
+	ItemTypeID int
+	GroupID    int64
+	UploaderID int64
+	From       string
+	To         string
+}
+
+// SearchResult is the model for an item matching the search query
+type SearchResult struct {
+	tableName          struct{}  `sql:"Items,alias:item"`
+	ItemID             int64     `sql:"item_id,pk"`
+	ItemName           string    `sql:"item_name"`
+	Description        string    `sql:"description"`
+	ItemTypeID         int       `sql:"item_type_id"`
+	GroupID            int64     `sql:"group_id"`
+	GroupName          string    `sql:"group_name"`
+	ItemPath           string    `sql:"item_path"`
+	CreatedBy          int64     `sql:"created_by"`
+	CreatedByFirstName string    `sql:"created_by_first_name"`
+	CreatedByLastName  string    `sql:"created_by_last_name"`
+	CreationTime       time.Time `sql:"creation_time"`
+	Rank               float32   `sql:"rank"`
+}
+
+// SearchView is the view model for the search page
+type SearchView struct {
+	Filter    *SearchFilter
+	Groups    []*GroupKeyVal
+	Uploaders []*UserKeyVal
+	Results   []*SearchResult
+}
+
+// SearchItems runs a full-text search over the items (name and description) and their comments
+// Only the items of the groups visible to the user are searched
+func SearchItems(log logger.MultiLogger, userID int64, filter *SearchFilter) ([]*SearchResult, error) {
+	// Get all the groups visible to the user
+	groups, err := GetAllGroupsKeyVal(userID)
+	if err != nil {
+		log.Errorf("Unable to get the groups for user - %d. Err: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var groupIDs []int64
+	for _, group := range groups {
+		if filter.GroupID > 0 && filter.GroupID != group.GroupID {
+			continue
+		}
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var results []*SearchResult
+	query := client.GetPGClient().Model(&results).
+		ColumnExpr(`"item".item_id, "item".item_name, "item".description, "item".item_type_id, g.group_id`).
+		ColumnExpr(`"item".item_path, "item".created_by, "item".creation_time, g.group_name`).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		ColumnExpr(`ts_rank("item".search_vector, q.query) + coalesce(cm.rank, 0) AS rank`).
+		// Items are searched in their primary group and the groups they are shared into
+		Join("JOIN groups AS g").
+		JoinOn("g.group_id = "+itemAccessGroupExpr, pg.Ints(groupIDs)).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"item\".created_by").
+		Join("CROSS JOIN websearch_to_tsquery('english', ?) AS q(query)", filter.Query).
+		Join(`LEFT JOIN LATERAL (
+			SELECT max(ts_rank(c.search_vector, q.query)) AS rank FROM comments AS c
+			WHERE c.item_id = "item".item_id AND NOT c.is_deleted AND c.search_vector @@ q.query
+			AND ("item".comment_scope = ? OR coalesce(c.group_id, "item".group_id) = g.group_id)
+		) AS cm ON true`, common.CommentScopeShared).
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		Where("(\"item\".search_vector @@ q.query OR cm.rank IS NOT NULL)")
+
+	if filter.ItemTypeID > 0 {
+		query = query.Where("\"item\".item_type_id = ?", filter.ItemTypeID)
+	}
+	if filter.UploaderID > 0 {
+		query = query.Where("\"item\".created_by = ?", filter.UploaderID)
+	}
+	if filter.From != "" {
+		from, err := time.Parse(common.SearchDateFormat, filter.From)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid start date - '%s'", filter.From)
+		}
+		query = query.Where("\"item\".creation_time >= ?", from)
+	}
+	if filter.To != "" {
+		to, err := time.Parse(common.SearchDateFormat, filter.To)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid end date - '%s'", filter.To)
+		}
+		// The end date is inclusive
+		query = query.Where("\"item\".creation_time < ?", to.AddDate(0, 0, 1))
+	}
+
+	err = query.OrderExpr("rank DESC").
+		OrderExpr(`"item".creation_time DESC`).
+		Limit(common.SearchMaxResults).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to search the items for query '%s'. Err: %s", filter.Query, err.Error())
+		return nil, fmt.Errorf("Unable to search the items at the moment")
+	}
+
+	return results, nil
+}
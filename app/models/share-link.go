@@ -0,0 +1,353 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+// ShareLink is the model for the links giving anonymous read-only access to an item or an album
+// Exactly one of ItemID and AlbumID is set, GroupID is the group of the item or the album
+type ShareLink struct {
+	tableName     struct{}  `sql:"ShareLinks,alias:sl"`
+	LinkID        int64     `sql:"link_id,pk"`
+	Token         string    `sql:"token"`
+	ItemID        int64     `sql:"item_id"`
+	AlbumID       int64     `sql:"album_id"`
+	GroupID       int64     `sql:"group_id"`
+	PasswordHash  string    `sql:"password_hash"`
+	AllowDownload bool      `sql:"allow_download"`
+	ExpiresAt     time.Time `sql:"expires_at"`
+	IsRevoked     bool      `sql:"is_revoked"`
+	CreatedBy     int64     `sql:"created_by"`
+	CreationTime  time.Time `sql:"creation_time"`
+}
+
+// ShareLinkView is the display model for the share links of an item or an album
+type ShareLinkView struct {
+	tableName          struct{}  `sql:"ShareLinks,alias:sl"`
+	LinkID             int64     `sql:"link_id,pk"`
+	Token              string    `sql:"token"`
+	ItemID             int64     `sql:"item_id"`
+	AlbumID            int64     `sql:"album_id"`
+	PasswordHash       string    `sql:"password_hash"`
+	AllowDownload      bool      `sql:"allow_download"`
+	ExpiresAt          time.Time `sql:"expires_at"`
+	IsRevoked          bool      `sql:"is_revoked"`
+	CreatedByFirstName string    `sql:"created_by_first_name"`
+	CreatedByLastName  string    `sql:"created_by_last_name"`
+	CreationTime       time.Time `sql:"creation_time"`
+	AccessCount        int       `sql:"access_count"`
+}
+
+// ShareLinkList is the view model for managing the share links of an item or an album
+type ShareLinkList struct {
+	ItemID  int64
+	AlbumID int64
+	Links   []*ShareLinkView
+}
+
+// ShareLinkAccess is the model for the access log of the share links
+type ShareLinkAccess struct {
+	tableName    struct{}  `sql:"ShareLinkAccess,alias:sla"`
+	AccessID     int64     `sql:"access_id,pk"`
+	LinkID       int64     `sql:"link_id"`
+	ItemID       int64     `sql:"item_id"`
+	Action       string    `sql:"action"`
+	IPAddress    string    `sql:"ip_address"`
+	UserAgent    string    `sql:"user_agent"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// ShareLinkDetails is the view model for the access log of a share link
+type ShareLinkDetails struct {
+	Link     *ShareLink
+	Accesses []*ShareLinkAccess
+}
+
+// SharedContent is the view model for the item or the album opened through a share link
+type SharedContent struct {
+	Link  *ShareLink
+	Item  *ItemView
+	Album *Album
+	Items []*ItemView
+}
+
+// IsExpired returns whether the expiry time of the link has passed, links without an expiry never expire
+func (model *ShareLink) IsExpired() bool {
+	return !model.ExpiresAt.IsZero() && time.Now().After(model.ExpiresAt)
+}
+
+// IsActive returns whether the link can still be used
+func (model *ShareLink) IsActive() bool {
+	return !model.IsRevoked && !model.IsExpired()
+}
+
+// HasPassword returns whether the link is protected by a password
+func (model *ShareLink) HasPassword() bool {
+	return model.PasswordHash != ""
+}
+
+// IsExpired returns whether the expiry time of the link has passed
+func (model *ShareLinkView) IsExpired() bool {
+	return !model.ExpiresAt.IsZero() && time.Now().After(model.ExpiresAt)
+}
+
+// HasPassword returns whether the link is protected by a password
+func (model *ShareLinkView) HasPassword() bool {
+	return model.PasswordHash != ""
+}
+
+// Add creates the share link with a new random token
+func (model *ShareLink) Add(log logger.MultiLogger) error {
+	token, err := common.RandomToken(common.ShareLinkTokenBytes)
+	if err != nil {
+		log.Errorf("Unable to generate the token for the share link. Err: %s", err.Error())
+		return fmt.Errorf("Unable to create the share link at the moment")
+	}
+	model.Token = token
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).Returning("*").Insert()
+	if err != nil {
+		log.Errorf("Unable to insert the share link into database. Err: %s", err.Error())
+		return fmt.Errorf("Unable to create the share link at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to create the share link at the moment")
+	}
+
+	return nil
+}
+
+// Revoke revokes the share link, the link and its access log are retained
+func (model *ShareLink) Revoke(log logger.MultiLogger) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("is_revoked = ?", true).
+		Where("is_revoked = ?", false).
+		Update()
+	if err != nil {
+		log.Errorf("Unable to revoke the share link - %d. Err: %s", model.LinkID, err.Error())
+		return fmt.Errorf("Unable to revoke the share link at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("The share link is already revoked")
+	}
+
+	return nil
+}
+
+// GetShareLinkByID returns the share link with the given ID
+func GetShareLinkByID(log logger.MultiLogger, linkID int64) (*ShareLink, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	link := &ShareLink{
+		LinkID: linkID,
+	}
+	err = client.GetPGClient().Select(link)
+	if err != nil {
+		log.Errorf("Unable to get the share link - %d. Err: %s", linkID, err.Error())
+		return nil, fmt.Errorf("The share link does not exist")
+	}
+
+	return link, nil
+}
+
+// GetShareLinkByToken returns the share link with the given token
+func GetShareLinkByToken(log logger.MultiLogger, token string) (*ShareLink, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	link := &ShareLink{}
+	err = client.GetPGClient().Model(link).
+		Where("token = ?", token).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the share link for the token. Err: %s", err.Error())
+		return nil, fmt.Errorf("The share link does not exist")
+	}
+
+	return link, nil
+}
+
+// GetShareLinks returns the share links of the item (or the album if itemID is 0), latest first
+func GetShareLinks(log logger.MultiLogger, itemID, albumID int64) (*ShareLinkList, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var links []*ShareLinkView
+	query := client.GetPGClient().Model(&links).
+		ColumnExpr(`"sl".link_id, "sl".token, "sl".item_id, "sl".album_id, "sl".password_hash`).
+		ColumnExpr(`"sl".allow_download, "sl".expires_at, "sl".is_revoked, "sl".creation_time`).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		ColumnExpr(`(SELECT count(*) FROM sharelinkaccess AS sla WHERE sla.link_id = "sl".link_id) AS access_count`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"sl\".created_by").
+		OrderExpr(`"sl".creation_time DESC`)
+	if itemID > 0 {
+		query = query.Where("\"sl\".item_id = ?", itemID)
+	} else {
+		query = query.Where("\"sl\".album_id = ?", albumID)
+	}
+
+	err = query.Select()
+	if err != nil {
+		log.Errorf("Unable to get the share links of item - %d / album - %d. Err: %s", itemID, albumID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the share links")
+	}
+
+	shareLinks := &ShareLinkList{
+		ItemID:  itemID,
+		AlbumID: albumID,
+		Links:   links,
+	}
+
+	return shareLinks, nil
+}
+
+// GetSharedContent returns the item or the album items the share link gives access to
+func GetSharedContent(log logger.MultiLogger, link *ShareLink) (*SharedContent, error) {
+	content := &SharedContent{
+		Link: link,
+	}
+
+	if link.AlbumID > 0 {
+		album, err := GetAlbumByID(log, link.AlbumID)
+		if err != nil {
+			// error is already logged
+			return nil, err
+		}
+		items, err := GetAlbumItems(log, link.AlbumID)
+		if err != nil {
+			// error is already logged
+			return nil, err
+		}
+		content.Album = album
+		content.Items = items
+
+		return content, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	item := &ItemView{
+		ItemID: link.ItemID,
+	}
+	err = client.GetPGClient().Model(item).WherePK().
+		ColumnExpr(itemViewColumns).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"item\".created_by").
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the item - %d of the share link - %d. Err: %s", link.ItemID, link.LinkID, err.Error())
+		return nil, fmt.Errorf("The shared item is no longer available")
+	}
+	content.Item = item
+
+	return content, nil
+}
+
+// CanAccessItem checks whether the item is the shared item or one of the items of the shared album
+// The items pending for approval cannot be accessed through the share links
+func (model *ShareLink) CanAccessItem(log logger.MultiLogger, itemID int64) (bool, error) {
+	if model.ItemID > 0 && model.ItemID != itemID {
+		return false, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return false, fmt.Errorf("Unable to process the request")
+	}
+
+	query := client.GetPGClient().Model((*Item)(nil)).
+		Where("item_id = ?", itemID).
+		Where("approval_status = ?", common.ItemApprovalPublished.GetStatusID())
+	if model.ItemID == 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM albumitems AS ai WHERE ai.album_id = ? AND ai.item_id = ?)", model.AlbumID, itemID)
+	}
+	count, err := query.Count()
+	if err != nil {
+		log.Errorf("Unable to check the item - %d of share link - %d. Err: %s", itemID, model.LinkID, err.Error())
+		return false, fmt.Errorf("Unable to process the request")
+	}
+
+	return count > 0, nil
+}
+
+// LogShareLinkAccess records an access through a share link
+// The log is best effort, failures are logged and do not fail the access
+func LogShareLinkAccess(log logger.MultiLogger, access *ShareLinkAccess) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return
+	}
+
+	_, err = client.GetPGClient().Model(access).Insert()
+	if err != nil {
+		log.Errorf("Unable to log the %s access of share link - %d. Err: %s", access.Action, access.LinkID, err.Error())
+	}
+}
+
+// GetShareLinkAccessLog returns the latest accesses through the share link
+func GetShareLinkAccessLog(log logger.MultiLogger, linkID int64) ([]*ShareLinkAccess, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var accesses []*ShareLinkAccess
+	err = client.GetPGClient().Model(&accesses).
+		Where("link_id = ?", linkID).
+		OrderExpr("access_id DESC").
+		Limit(common.ShareLinkAccessLogSize).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the access log of share link - %d. Err: %s", linkID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the access log of the share link")
+	}
+
+	return accesses, nil
+}
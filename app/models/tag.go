@@ -0,0 +1,220 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/revel/revel/logger"
+	"github.com/sp-share/app/common"
+	"github.com/sp-share/app/database"
+)
+
+const (
+	// MaxTagsPerItem is the maximum number of tags that can be attached to an item
+	MaxTagsPerItem = 10
+	// MaxTagLength is the maximum number of characters allowed in a tag
+	MaxTagLength = 30
+)
+
+var tagRegex = regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
+
+// Tag is the model for the tags defined within a group
+type Tag struct {
+	tableName    struct{}  `sql:"Tags,alias:tag"`
+	TagID        int64     `sql:"tag_id,pk"`
+	TagName      string    `sql:"tag_name"`
+	GroupID      int64     `sql:"group_id"`
+	CreatedBy    int64     `sql:"created_by"`
+	CreationTime time.Time `sql:"creation_time"`
+}
+
+// ItemTag holds the mapping of items and tags
+type ItemTag struct {
+	tableName struct{} `sql:"ItemTags"`
+	ItemID    int64    `sql:"item_id,pk"`
+	TagID     int64    `sql:"tag_id,pk"`
+}
+
+// TagItems is the view model for browsing the items of a group with a given tag
+type TagItems struct {
+	GroupID   int64
+	GroupName string
+	TagName   string
+	Items     []*ItemView
+}
+
+// ParseTags splits a comma separated list of tags into normalized tag names
+// Tags are lower-cased and de-duplicated
+func ParseTags(tags string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("Tag '%s' should be %d characters or less", tag, MaxTagLength)
+		}
+		if !tagRegex.MatchString(tag) {
+			return nil, fmt.Errorf("Tag '%s' should start with an alphabet or a number and must include only alphabets (a-z), numbers (0-9) and symbols (_ and -)", tag)
+		}
+
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	if len(result) > MaxTagsPerItem {
+		return nil, fmt.Errorf("Only %d tags are allowed per item", MaxTagsPerItem)
+	}
+
+	return result, nil
+}
+
+// GetTagsForGroup returns all the tags defined in a group
+func GetTagsForGroup(log logger.MultiLogger, groupID int64) ([]*Tag, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var tags []*Tag
+	err = client.GetPGClient().Model(&tags).
+		Where("group_id = ?", groupID).
+		Order("tag_name ASC").
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get tags for the group - %d. Err: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the tags of the group")
+	}
+
+	return tags, nil
+}
+
+// GetTagsForItem returns all the tags attached to an item
+func GetTagsForItem(log logger.MultiLogger, itemID int64) ([]*Tag, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var tags []*Tag
+	err = client.GetPGClient().Model(&tags).
+		ColumnExpr(`"tag".*`).
+		Join("JOIN itemtags AS it").
+		JoinOn("it.tag_id = \"tag\".tag_id").
+		Where("it.item_id = ?", itemID).
+		Order("tag_name ASC").
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get tags for the item - %d. Err: %s", itemID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the tags of the item")
+	}
+
+	return tags, nil
+}
+
+// SetItemTags replaces the tags attached to the item with the given tag names
+// Tags which do not exist in the item's group yet are created
+func (model *Item) SetItemTags(log logger.MultiLogger, tagNames []string, userID int64) error {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		// Remove the existing tags of the item
+		_, err := tx.Model((*ItemTag)(nil)).Where("item_id = ?", model.ItemID).Delete()
+		if err != nil {
+			return err
+		}
+
+		if len(tagNames) == 0 {
+			return nil
+		}
+
+		// Create the tags missing in the group
+		tags := make([]*Tag, len(tagNames))
+		for index, tagName := range tagNames {
+			tags[index] = &Tag{
+				TagName:   tagName,
+				GroupID:   model.GroupID,
+				CreatedBy: userID,
+			}
+		}
+		_, err = tx.Model(&tags).OnConflict("(group_id, tag_name) DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+
+		// Fetch the IDs of all the tags (new as well as existing)
+		var groupTags []*Tag
+		err = tx.Model(&groupTags).
+			Where("group_id = ?", model.GroupID).
+			Where("tag_name in (?)", pg.Strings(tagNames)).
+			Select()
+		if err != nil {
+			return err
+		}
+
+		itemTags := make([]*ItemTag, len(groupTags))
+		for index, tag := range groupTags {
+			itemTags[index] = &ItemTag{
+				ItemID: model.ItemID,
+				TagID:  tag.TagID,
+			}
+		}
+		_, err = tx.Model(&itemTags).OnConflict("DO NOTHING").Insert()
+		return err
+	})
+	if err != nil {
+		log.Errorf("Unable to update the tags of the item - %d. Err: %s", model.ItemID, err.Error())
+		return fmt.Errorf("Unable to update the tags of the item")
+	}
+
+	return nil
+}
+
+// GetItemsByTag returns all the uploaded items of a group which are tagged with the given tag
+func GetItemsByTag(log logger.MultiLogger, groupID int64, tagName string) ([]*ItemView, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get the database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var items []*ItemView
+	err = client.GetPGClient().Model(&items).
+		ColumnExpr(itemViewColumns).
+		ColumnExpr(`u.first_name AS created_by_first_name, u.last_name AS created_by_last_name`).
+		Join("JOIN appuser AS u").
+		JoinOn("u.user_id = \"item\".created_by").
+		Join("JOIN itemtags AS it").
+		JoinOn("it.item_id = \"item\".item_id").
+		Join("JOIN tags AS t").
+		JoinOn("t.tag_id = it.tag_id").
+		Where("t.group_id = ?", groupID).
+		Where("t.tag_name = ?", tagName).
+		Where("\"item\".uploaded = ?", true).
+		Where("\"item\".approval_status = ?", common.ItemApprovalPublished.GetStatusID()).
+		OrderExpr(`"item".creation_time DESC`).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get items for the tag '%s' in group - %d. Err: %s", tagName, groupID, err.Error())
+		return nil, fmt.Errorf("Unable to fetch the items with the tag")
+	}
+
+	return items, nil
+}
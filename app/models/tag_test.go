@@ -0,0 +1,77 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"blank tags", " , ,, ", nil},
+		{"single tag", "holiday", []string{"holiday"}},
+		{"trimmed and lower-cased", " Holiday , BEACH ", []string{"holiday", "beach"}},
+		{"duplicates", "beach,Beach, beach", []string{"beach"}},
+		{"symbols", "new-year_2020, 2020", []string{"new-year_2020", "2020"}},
+		{"max length", strings.Repeat("a", MaxTagLength), []string{strings.Repeat("a", MaxTagLength)}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseTags(test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error - %s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseTagsInvalid(t *testing.T) {
+	tooMany := make([]string, MaxTagsPerItem+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("a", i+1)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"too long", strings.Repeat("a", MaxTagLength+1)},
+		{"starts with a symbol", "-beach"},
+		{"space within", "sea side"},
+		{"invalid symbol", "beach!"},
+		{"non ascii", "plage-été"},
+		{"too many tags", strings.Join(tooMany, ",")},
+	}
+
+	for _, test := range tests {
+		got, err := ParseTags(test.input)
+		if err == nil {
+			t.Errorf("%s: expected an error, got %q", test.name, got)
+		}
+	}
+}
+
+func TestParseTagsLimit(t *testing.T) {
+	// The duplicates do not count towards the limit of the tags
+	tags := make([]string, MaxTagsPerItem)
+	for i := range tags {
+		tags[i] = strings.Repeat("a", i+1)
+	}
+	input := strings.Join(tags, ",") + "," + tags[0]
+
+	got, err := ParseTags(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %s", err.Error())
+	}
+	if len(got) != MaxTagsPerItem {
+		t.Errorf("got %d tags, want %d", len(got), MaxTagsPerItem)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/revel/revel/logger"
 	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/database"
@@ -14,7 +15,7 @@ type UserGroupMap struct {
 	tableName      struct{}  `sql:"UserGroupMap"`
 	UserID         int64     `sql:"user_id,pk"`
 	GroupID        int64     `sql:"group_id,pk"`
-	IsLeader       bool      `sql:"is_leader,default:false"`
+	Role           int       `sql:"role"`
 	CreatedBy      int64     `sql:"created_by"`
 	CreationTime   time.Time `sql:"creation_time"`
 	LastUpdated    time.Time `sql:"last_updated"`
@@ -27,7 +28,7 @@ type UserGroupMapView struct {
 	UserID             int64     `sql:"user_id"`
 	GroupID            int64     `sql:"group_id"`
 	GroupName          string    `sql:"group_name"`
-	IsLeader           bool      `sql:"is_leader"`
+	Role               int       `sql:"role"`
 	Username           string    `sql:"username"`
 	FirstName          string    `sql:"first_name"`
 	LastName           string    `sql:"last_name"`
@@ -37,43 +38,23 @@ type UserGroupMapView struct {
 	WorkflowStatus     int       `sql:"workflow_status"`
 }
 
-// Add adds a user-group mapping into database
-func (model *UserGroupMap) Add(log logger.MultiLogger, userID int64) error {
-	// Get Database client
-	client, err := database.GetClient()
-	if err != nil {
-		log.Errorf("Unable to get database client. Err: %s", err.Error())
-		return fmt.Errorf("Unable to process the request")
-	}
-
-	// Check if user has sufficient previleges
-	existingUserGroupMap := &UserGroupMap{
-		UserID:  userID,
-		GroupID: model.GroupID,
-	}
-
-	err = client.GetPGClient().Select(existingUserGroupMap)
-	if err != nil {
-		log.Errorf("Unable to fetch user permissions. Error: %s", err.Error())
-		return fmt.Errorf("Unable to process the request")
-	}
-
-	if !existingUserGroupMap.IsLeader {
-		return fmt.Errorf("You do not have sufficient privileges to add users to the group")
+// GetRole returns the effective role of the member, a request for the leader role is not effective till it is approved
+func (model *UserGroupMap) GetRole() common.GroupRole {
+	role := common.GroupRole(model.Role)
+	if role == common.GroupRoleLeader && model.WorkflowStatus != common.WorkflowStatusApproved.GetStatusID() {
+		return common.GroupRoleContributor
 	}
+	return role
+}
 
-	// Insert group model into database
-	model.WorkflowStatus = common.WorkflowStatusApproved.GetStatusID()
-	res, err := client.GetPGClient().Model(model).OnConflict("DO NOTHING").Insert()
-	if err != nil {
-		log.Errorf("Unable to insert user into database. Err: %s", err.Error())
-		return fmt.Errorf("Unable to process the request")
-	}
-	if res.RowsAffected() < 1 {
-		return fmt.Errorf("Unable to process the request")
-	}
+// IsLeader returns whether the role of the member is (or is requested to be) the leader
+func (model *UserGroupMapView) IsLeader() bool {
+	return model.Role == common.GroupRoleLeader.GetRoleID()
+}
 
-	return nil
+// GetRoleName returns the name of the role of the member
+func (model *UserGroupMapView) GetRoleName() string {
+	return common.GroupRole(model.Role).GetString()
 }
 
 // UpgradeToGroupLead upgrades a given mapping to include group lead access
@@ -104,7 +85,7 @@ func (model *UserGroupMap) UpgradeToGroupLead(log logger.MultiLogger) error {
 	wfStatus := common.WorkflowStatusPending.GetStatusID()
 	res, err := client.GetPGClient().Model(model).WherePK().
 		Set("workflow_status = ?", wfStatus).
-		Set("is_leader = ?", true).
+		Set("role = ?", common.GroupRoleLeader.GetRoleID()).
 		Update()
 	if err != nil {
 		log.Errorf("Unable to update the user-group mapping. Error: %s", err.Error())
@@ -118,6 +99,150 @@ func (model *UserGroupMap) UpgradeToGroupLead(log logger.MultiLogger) error {
 	return nil
 }
 
+// GetGroupRole returns the effective role of the user in the group, GroupRoleNone if the user is not a member
+func GetGroupRole(log logger.MultiLogger, userID, groupID int64) (common.GroupRole, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return common.GroupRoleNone, fmt.Errorf("Unable to process the request")
+	}
+
+	userGroupMap := &UserGroupMap{
+		UserID:  userID,
+		GroupID: groupID,
+	}
+	err = client.GetPGClient().Select(userGroupMap)
+	if err == pg.ErrNoRows {
+		return common.GroupRoleNone, nil
+	}
+	if err != nil {
+		log.Errorf("Unable to fetch user permissions. Error: %s", err.Error())
+		return common.GroupRoleNone, fmt.Errorf("Unable to process the request")
+	}
+
+	return userGroupMap.GetRole(), nil
+}
+
+// GetGroupRoles returns the effective roles of the user in all the groups the user is a member of
+func GetGroupRoles(log logger.MultiLogger, userID int64) (map[int64]common.GroupRole, error) {
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	var mappings []*UserGroupMap
+	err = client.GetPGClient().Model(&mappings).
+		Where("user_id = ?", userID).
+		Select()
+	if err != nil {
+		log.Errorf("Unable to fetch the roles of user - %d. Error: %s", userID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	roles := make(map[int64]common.GroupRole)
+	for _, mapping := range mappings {
+		roles[mapping.GroupID] = mapping.GetRole()
+	}
+
+	return roles, nil
+}
+
+// UpdateRole changes the role of the member in the group, leaders can promote members to leaders and demote leaders
+// The owner of the group stays a leader, and the group is never left without an approved leader
+// A pending request of the member for the group lead access is settled by the change
+func (model *UserGroupMap) UpdateRole(log logger.MultiLogger, role common.GroupRole, actorID int64) error {
+	if !role.IsValid() {
+		return fmt.Errorf("Invalid role for the member")
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	existingMapping := &UserGroupMap{
+		UserID:  model.UserID,
+		GroupID: model.GroupID,
+	}
+	err = client.GetPGClient().Select(existingMapping)
+	if err == pg.ErrNoRows {
+		return fmt.Errorf("The user is not a member of the group")
+	}
+	if err != nil {
+		log.Errorf("Unable to fetch the user-group mapping. Error: %s", err.Error())
+		return fmt.Errorf("Unable to process the request")
+	}
+
+	previousRole := existingMapping.GetRole()
+	if previousRole == role && existingMapping.WorkflowStatus == common.WorkflowStatusApproved.GetStatusID() {
+		return fmt.Errorf("The user is already a %s of the group", role.GetString())
+	}
+
+	if previousRole == common.GroupRoleLeader && role != common.GroupRoleLeader {
+		group := &Group{
+			GroupID: model.GroupID,
+		}
+		err = client.GetPGClient().Select(group)
+		if err != nil {
+			log.Errorf("Unable to get the group - %d. Error: %s", model.GroupID, err.Error())
+			return fmt.Errorf("Unable to process the request")
+		}
+		if group.CreatedBy == model.UserID {
+			return fmt.Errorf("The owner of the group must remain a leader. Please transfer the ownership first")
+		}
+
+		leaderCount, err := client.GetPGClient().Model((*UserGroupMap)(nil)).
+			Where("group_id = ?", model.GroupID).
+			Where("user_id <> ?", model.UserID).
+			Where("role = ?", common.GroupRoleLeader.GetRoleID()).
+			Where("workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+			Count()
+		if err != nil {
+			log.Errorf("Unable to count the leaders of group - %d. Error: %s", model.GroupID, err.Error())
+			return fmt.Errorf("Unable to process the request")
+		}
+		if leaderCount == 0 {
+			return fmt.Errorf("The last leader of the group cannot be demoted. Please make another member a leader of the group first")
+		}
+	}
+
+	res, err := client.GetPGClient().Model(model).WherePK().
+		Set("role = ?", role.GetRoleID()).
+		Set("workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+		Set("last_updated = now()").
+		Update()
+	if err != nil {
+		log.Errorf("Unable to update the role of user - %d in group - %d. Error: %s", model.UserID, model.GroupID, err.Error())
+		return fmt.Errorf("Unable to update the role at the moment")
+	}
+	if res.RowsAffected() < 1 {
+		return fmt.Errorf("Unable to update the role at the moment")
+	}
+
+	event := &GroupEvent{
+		GroupID:      model.GroupID,
+		ActorID:      actorID,
+		EventType:    common.GroupEventRoleChanged.GetTypeID(),
+		TargetUserID: model.UserID,
+		Details:      role.GetString(),
+	}
+	switch {
+	case role == common.GroupRoleLeader:
+		event.EventType = common.GroupEventLeaderPromoted.GetTypeID()
+		event.Details = ""
+	case previousRole == common.GroupRoleLeader:
+		event.EventType = common.GroupEventLeaderDemoted.GetTypeID()
+	}
+	logGroupEvent(log, event)
+
+	return nil
+}
+
 // GetAllUserGroupMappingForAGroup returns list of all the users that are pending for admin approval
 func GetAllUserGroupMappingForAGroup(groupID int64) ([]*UserGroupMapView, error) {
 	var users []*UserGroupMapView
@@ -130,7 +255,7 @@ func GetAllUserGroupMappingForAGroup(groupID int64) ([]*UserGroupMapView, error)
 
 	// Get all the groups
 	err = client.GetPGClient().Model(&users).
-		ColumnExpr(`"ugm".user_id, "ugm".group_id, "ugm".is_leader, "ugm".creation_time, "ugm".workflow_status`).
+		ColumnExpr(`"ugm".user_id, "ugm".group_id, "ugm".role, "ugm".creation_time, "ugm".workflow_status`).
 		ColumnExpr(`u.first_name, u.last_name, u.username`).
 		ColumnExpr(`au.first_name AS created_by_first_name, au.last_name AS created_by_last_name`).
 		Join("JOIN appuser AS u").
@@ -169,7 +294,7 @@ func GetAllUserGroupMappingPendingApproval(userID int64) ([]*UserGroupMapView, e
 	actionID := common.WorkflowStatusPending.GetStatusID()
 	// Get all the user-group mappings with pending status
 	err = client.GetPGClient().Model(&users).
-		ColumnExpr(`"ugm".user_id, "ugm".group_id, "ugm".is_leader, "ugm".creation_time, "ugm".workflow_status`).
+		ColumnExpr(`"ugm".user_id, "ugm".group_id, "ugm".role, "ugm".creation_time, "ugm".workflow_status`).
 		ColumnExpr(`u.first_name, u.last_name, u.username`).
 		ColumnExpr(`au.first_name AS created_by_first_name, au.last_name AS created_by_last_name`).
 		ColumnExpr(`g.group_name`).
@@ -189,7 +314,8 @@ func GetAllUserGroupMappingPendingApproval(userID int64) ([]*UserGroupMapView, e
 }
 
 // ApproveOrReject is used by admin to approve/reject a group creation
-func (model *UserGroupMap) ApproveOrReject(log logger.MultiLogger, approve bool) error {
+// adminID is the admin handling the request, approved leader requests are logged as promotions
+func (model *UserGroupMap) ApproveOrReject(log logger.MultiLogger, approve bool, adminID int64) error {
 	// Note: Authz check is already done at this point
 
 	// Get Database client
@@ -232,6 +358,15 @@ func (model *UserGroupMap) ApproveOrReject(log logger.MultiLogger, approve bool)
 		if res.RowsAffected() < 1 {
 			return fmt.Errorf("Unable to perform the action at the moment")
 		}
+
+		if existingMapping.Role == common.GroupRoleLeader.GetRoleID() && groupModel.CreatedBy != model.UserID {
+			logGroupEvent(log, &GroupEvent{
+				GroupID:      model.GroupID,
+				ActorID:      adminID,
+				EventType:    common.GroupEventLeaderPromoted.GetTypeID(),
+				TargetUserID: model.UserID,
+			})
+		}
 	} else {
 		// Check the request type - Request for a new group, or request for access upgrade
 		if groupModel.CreatedBy == model.UserID {
@@ -240,13 +375,13 @@ func (model *UserGroupMap) ApproveOrReject(log logger.MultiLogger, approve bool)
 			return fmt.Errorf("This request is associated with new group. Please check the 'Create Group' requests")
 		}
 
-		if existingMapping.IsLeader {
+		if existingMapping.Role == common.GroupRoleLeader.GetRoleID() {
 			// This is a request for upgrade
-			// We downgrade the request to 'member' in this case
+			// We downgrade the request to 'contributor' in this case
 			model.WorkflowStatus = common.WorkflowStatusApproved.GetStatusID()
 			res, err := client.GetPGClient().Model(model).WherePK().
 				Set("workflow_status = ?", model.WorkflowStatus).
-				Set("is_leader = ?", false).
+				Set("role = ?", common.GroupRoleContributor.GetRoleID()).
 				Update()
 			if err != nil {
 				log.Errorf("Unable to update the user-group mapping. Error: %s", err.Error())
@@ -261,3 +396,187 @@ func (model *UserGroupMap) ApproveOrReject(log logger.MultiLogger, approve bool)
 
 	return nil
 }
+
+// RemoveGroupMember removes the user from the group, actorID is the user removing the member (the user itself when leaving)
+// items is one of MemberItemsKeep, MemberItemsTransfer and MemberItemsDelete, and decides what happens to the items
+// the user uploaded to the group. Transferred items are given to the actor if it is a leader, or else to another leader.
+// The owner and the last leader of the group cannot leave. The deleted items are returned so that their files can be removed
+func RemoveGroupMember(log logger.MultiLogger, groupID, userID, actorID int64, items string) ([]*Item, error) {
+	if items != common.MemberItemsKeep && items != common.MemberItemsTransfer && items != common.MemberItemsDelete {
+		return nil, fmt.Errorf("Invalid option for the items of the member - '%s'", items)
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	membership := &UserGroupMap{
+		UserID:  userID,
+		GroupID: groupID,
+	}
+	err = client.GetPGClient().Select(membership)
+	if err == pg.ErrNoRows {
+		return nil, fmt.Errorf("The user is not a member of the group")
+	}
+	if err != nil {
+		log.Errorf("Unable to fetch the user-group mapping. Error: %s", err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	// The group always stays with its owner, the ownership is transferred before the owner leaves
+	group := &Group{
+		GroupID: groupID,
+	}
+	err = client.GetPGClient().Select(group)
+	if err != nil {
+		log.Errorf("Unable to fetch the group - %d. Error: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+	if group.CreatedBy == userID {
+		if actorID == userID {
+			return nil, fmt.Errorf("The owner of the group cannot leave it. Please transfer the ownership of the group first")
+		}
+		return nil, fmt.Errorf("The owner of the group cannot be removed from it. The ownership of the group has to be transferred first")
+	}
+
+	// Get the other approved leaders of the group
+	var leaders []*UserGroupMap
+	err = client.GetPGClient().Model(&leaders).
+		Where("group_id = ?", groupID).
+		Where("user_id <> ?", userID).
+		Where("role = ?", common.GroupRoleLeader.GetRoleID()).
+		Where("workflow_status = ?", common.WorkflowStatusApproved.GetStatusID()).
+		Order("creation_time ASC").
+		Select()
+	if err != nil {
+		log.Errorf("Unable to fetch the leaders of group - %d. Error: %s", groupID, err.Error())
+		return nil, fmt.Errorf("Unable to process the request")
+	}
+
+	if membership.GetRole() == common.GroupRoleLeader && len(leaders) == 0 {
+		return nil, fmt.Errorf("The last leader of the group cannot leave. Please make another member a leader of the group first")
+	}
+
+	var newOwnerID int64
+	if items == common.MemberItemsTransfer {
+		if len(leaders) == 0 {
+			return nil, fmt.Errorf("There is no other leader in the group to transfer the items to")
+		}
+		newOwnerID = leaders[0].UserID
+		for _, leader := range leaders {
+			if leader.UserID == actorID {
+				newOwnerID = actorID
+				break
+			}
+		}
+	}
+
+	var deletedItems []*Item
+	var itemCount int
+	err = client.GetPGClient().RunInTransaction(func(tx *pg.Tx) error {
+		res, err := tx.Model(membership).WherePK().Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return pg.ErrNoRows
+		}
+
+		_, err = tx.Model((*GroupLastSeen)(nil)).
+			Where("user_id = ?", userID).
+			Where("group_id = ?", groupID).
+			Delete()
+		if err != nil {
+			return err
+		}
+
+		switch items {
+		case common.MemberItemsTransfer:
+			res, err = tx.Model((*Item)(nil)).
+				Set("created_by = ?", newOwnerID).
+				Where("group_id = ?", groupID).
+				Where("created_by = ?", userID).
+				Update()
+			if err != nil {
+				return err
+			}
+			itemCount = res.RowsAffected()
+		case common.MemberItemsDelete:
+			err = tx.Model(&deletedItems).
+				Where("group_id = ?", groupID).
+				Where("created_by = ?", userID).
+				Select()
+			if err != nil {
+				return err
+			}
+
+			// The items of the user in other groups are no longer shared into this group
+			_, err = tx.Model((*ItemShare)(nil)).
+				Where("group_id = ?", groupID).
+				Where("item_id in (SELECT item_id FROM items WHERE created_by = ?)", userID).
+				Delete()
+			if err != nil {
+				return err
+			}
+
+			if len(deletedItems) == 0 {
+				return nil
+			}
+			itemIDs := make([]int64, 0, len(deletedItems))
+			for _, item := range deletedItems {
+				itemIDs = append(itemIDs, item.ItemID)
+			}
+
+			// The comments and the rest of the details of the items cascade
+			res, err = tx.Model((*Item)(nil)).Where("item_id in (?)", pg.Ints(itemIDs)).Delete()
+			if err != nil {
+				return err
+			}
+			itemCount = res.RowsAffected()
+		}
+
+		return nil
+	})
+	if err == pg.ErrNoRows {
+		return nil, fmt.Errorf("The user is not a member of the group")
+	}
+	if err != nil {
+		log.Errorf("Unable to remove user - %d from group - %d. Error: %s", userID, groupID, err.Error())
+		return nil, fmt.Errorf("Unable to remove the user from the group at the moment")
+	}
+
+	for _, item := range deletedItems {
+		logGroupEvent(log, &GroupEvent{
+			GroupID:   groupID,
+			ActorID:   actorID,
+			EventType: common.GroupEventItemDeleted.GetTypeID(),
+			ItemID:    item.ItemID,
+			ItemName:  item.ItemName,
+		})
+	}
+
+	event := &GroupEvent{
+		GroupID:      groupID,
+		ActorID:      actorID,
+		EventType:    common.GroupEventMemberRemoved.GetTypeID(),
+		TargetUserID: userID,
+	}
+	if actorID == userID {
+		event.EventType = common.GroupEventMemberLeft.GetTypeID()
+		event.TargetUserID = 0
+	}
+	switch items {
+	case common.MemberItemsKeep:
+		event.Details = "items kept in the group"
+	case common.MemberItemsTransfer:
+		event.Details = fmt.Sprintf("%d items transferred to a leader", itemCount)
+	case common.MemberItemsDelete:
+		event.Details = fmt.Sprintf("%d items deleted", itemCount)
+	}
+	logGroupEvent(log, event)
+
+	return deletedItems, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/revel/revel/logger"
 	"github.com/sp-share/app/common"
 	"github.com/sp-share/app/database"
@@ -28,7 +29,7 @@ type User struct {
 
 // UserKeyVal holds the key-value pair for user model
 type UserKeyVal struct {
-	tableName struct{} `sql:"AppUser"`
+	tableName struct{} `sql:"AppUser,alias:usr"`
 	UserID    int64    `sql:"user_id,pk"`
 	FirstName string   `sql:"first_name"`
 	LastName  string   `sql:"last_name"`
@@ -50,6 +51,11 @@ func (model *User) GetUserID() int64 {
 	return model.UserID
 }
 
+// IsApproved checks whether the user is approved by the admin
+func (model *User) IsApproved() bool {
+	return model.WorkflowStatus == common.WorkflowStatusApproved.GetStatusID()
+}
+
 // GetUserName returns the name of the logged-in user
 func (model *User) GetUserName() string {
 	if model == nil {
@@ -245,3 +251,34 @@ func GetAllUsersKeyVal(log logger.MultiLogger) ([]*UserKeyVal, error) {
 
 	return users, nil
 }
+
+// GetUsersInGroups returns list of all the users mapped to any of the given groups
+func GetUsersInGroups(log logger.MultiLogger, groupIDs []int64) ([]*UserKeyVal, error) {
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	// Get Database client
+	client, err := database.GetClient()
+	if err != nil {
+		log.Errorf("Unable to get database client. Err: %s", err.Error())
+		return nil, fmt.Errorf("Unable to get the list of users")
+	}
+
+	var users []*UserKeyVal
+
+	err = client.GetPGClient().Model(&users).
+		ColumnExpr(`DISTINCT "usr".user_id, "usr".first_name, "usr".last_name`).
+		Join("JOIN usergroupmap AS ugm").
+		JoinOn("ugm.user_id = \"usr\".user_id").
+		Where("ugm.group_id in (?)", pg.Ints(groupIDs)).
+		Order("first_name ASC").
+		Order("last_name ASC").
+		Select()
+	if err != nil {
+		log.Errorf("Unable to get the list of users in groups from database. Error: %s", err.Error())
+		return nil, fmt.Errorf("Unable to get the list of users")
+	}
+
+	return users, nil
+}
@@ -0,0 +1,119 @@
+// Infinite scroll for the item feed on the home page
+(function () {
+    var list = document.getElementById('feedItems');
+    var sentinel = document.getElementById('feedSentinel');
+    var filters = document.getElementById('feedFilters');
+    if (!list || !sentinel) {
+        return;
+    }
+
+    var cursor = sentinel.getAttribute('data-next-cursor');
+    var loading = false;
+
+    function element(tag, attributes, text) {
+        var node = document.createElement(tag);
+        Object.keys(attributes || {}).forEach(function (name) {
+            node.setAttribute(name, attributes[name]);
+        });
+        if (text !== undefined) {
+            node.textContent = text;
+        }
+        return node;
+    }
+
+    function renderItem(item) {
+        var container = element('div', { 'class': 'previewImageContainer' });
+
+        var description = element('p');
+        description.appendChild(element('label', {}, item.description));
+        container.appendChild(description);
+
+        if (item.itemTypeID === 1) {
+            container.appendChild(element('img', {
+                'class': 'imgPreview', 'src': item.itemPath, 'alt': '', 'width': 500, 'height': 400
+            }));
+        } else if (item.itemTypeID === 2) {
+            var video = element('video', { 'width': 500, 'height': 400, 'controls': '' });
+            video.appendChild(element('source', { 'src': item.itemPath, 'type': 'video/mp4' }));
+            container.appendChild(video);
+        }
+
+        var label = element('label', { 'class': 'lblImageName' });
+        if (item.isNew) {
+            label.appendChild(element('span', { 'class': 'badge badge-success' }, 'New'));
+            label.appendChild(document.createTextNode(' '));
+        }
+        label.appendChild(element('a', { 'href': '/item/' + item.itemID + '?group=' + item.groupID }, item.itemName));
+        label.appendChild(document.createTextNode(' | '));
+        label.appendChild(element('a', { 'href': '/groups/' + item.groupID }, item.groupName));
+        label.appendChild(document.createTextNode(' | ' + item.commentCount + ' comments'));
+        if (item.newCommentCount) {
+            label.appendChild(document.createTextNode(' '));
+            label.appendChild(element('span', { 'class': 'badge badge-primary' }, item.newCommentCount + ' new'));
+        }
+        (item.topReactions || []).forEach(function (reaction) {
+            label.appendChild(document.createTextNode(' | ' + reaction.emoji + ' ' + reaction.count));
+        });
+        var footer = element('p', { 'class': 'text-center' });
+        footer.appendChild(label);
+        container.appendChild(footer);
+
+        var li = element('li');
+        li.appendChild(container);
+        list.appendChild(li);
+    }
+
+    function loadNextPage() {
+        if (loading || !cursor) {
+            return;
+        }
+        loading = true;
+
+        var params = [];
+        if (filters) {
+            ['group', 'itemType', 'sort', 'unseen'].forEach(function (name) {
+                var field = filters.elements[name];
+                if (field && field.value && (field.type !== 'checkbox' || field.checked)) {
+                    params.push(name + '=' + encodeURIComponent(field.value));
+                }
+            });
+        }
+        params.push('cursor=' + encodeURIComponent(cursor));
+
+        var xhr = new XMLHttpRequest();
+        xhr.open('GET', '/home/feed?' + params.join('&'));
+        xhr.onload = function () {
+            loading = false;
+            if (xhr.status !== 200) {
+                cursor = '';
+                return;
+            }
+            var page = JSON.parse(xhr.responseText);
+            (page.items || []).forEach(renderItem);
+            cursor = page.nextCursor;
+
+            // Keep loading while the end of the list is still in view
+            if (sentinel.getBoundingClientRect().top < window.innerHeight) {
+                loadNextPage();
+            }
+        };
+        xhr.onerror = function () {
+            loading = false;
+        };
+        xhr.send();
+    }
+
+    if ('IntersectionObserver' in window) {
+        new IntersectionObserver(function (entries) {
+            if (entries[0].isIntersecting) {
+                loadNextPage();
+            }
+        }, { rootMargin: '400px' }).observe(sentinel);
+    } else {
+        window.addEventListener('scroll', function () {
+            if (sentinel.getBoundingClientRect().top < window.innerHeight + 400) {
+                loadNextPage();
+            }
+        });
+    }
+})();
@@ -0,0 +1,59 @@
+// Autocomplete for the comma separated tag input using the tags of the selected group
+(function () {
+    var input = document.getElementById('tagsInput');
+    var list = document.getElementById('tagSuggestions');
+    if (!input || !list) {
+        return;
+    }
+
+    var groupTags = [];
+
+    function refresh() {
+        var parts = input.value.split(',');
+        var current = parts.pop().trim().toLowerCase();
+        var prefix = parts.length ? parts.join(',') + ', ' : '';
+        var used = parts.map(function (part) {
+            return part.trim().toLowerCase();
+        });
+
+        list.innerHTML = '';
+        groupTags.forEach(function (tag) {
+            if (used.indexOf(tag) !== -1 || tag.indexOf(current) !== 0) {
+                return;
+            }
+            var option = document.createElement('option');
+            option.value = prefix + tag;
+            list.appendChild(option);
+        });
+    }
+
+    function loadTags(groupID) {
+        groupTags = [];
+        if (!groupID) {
+            refresh();
+            return;
+        }
+
+        var xhr = new XMLHttpRequest();
+        xhr.open('GET', '/groups/' + groupID + '/tags');
+        xhr.onload = function () {
+            if (xhr.status === 200) {
+                groupTags = JSON.parse(xhr.responseText) || [];
+            }
+            refresh();
+        };
+        xhr.send();
+    }
+
+    input.addEventListener('input', refresh);
+
+    var groupSelect = document.querySelector('select[name="group"]');
+    if (groupSelect) {
+        groupSelect.addEventListener('change', function () {
+            loadTags(groupSelect.value);
+        });
+        loadTags(groupSelect.value);
+    } else {
+        loadTags(input.getAttribute('data-group-id'));
+    }
+})();